@@ -0,0 +1,228 @@
+// Package backup dump the whole database to a versioned JSON document and load it back.
+package backup
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"wrong.wang/x/go-isso/isso"
+)
+
+// Version is the format version of the backup document.
+const Version = 1
+
+const pageSize = 1000
+
+// every mode stored in database, used to fetch all comments.
+const allModes = isso.ModeAccepted | isso.ModeModeration | isso.ModeDeleted
+
+// Storage is the storage backup need.
+type Storage interface {
+	FetchThreads(ctx context.Context) ([]isso.Thread, error)
+	RestoreThread(ctx context.Context, t isso.Thread) error
+	FetchComments(ctx context.Context, mode int, orderBy string, asc bool, limit, offset int64) ([]isso.Comment, error)
+	RestoreComment(ctx context.Context, c isso.Comment) error
+	FetchPreferences(ctx context.Context) (map[string]string, error)
+	GetPreference(ctx context.Context, key string) (string, error)
+	SetPreference(ctx context.Context, key string, value string) error
+	UpdatePreference(ctx context.Context, key string, value string) error
+}
+
+// schemaVersionKey is the preference recording migrations of target storage,
+// it is never restored.
+const schemaVersionKey = "schema_version"
+
+// Document is the backup file.
+type Document struct {
+	Version     int          `json:"version"`
+	Created     time.Time    `json:"created"`
+	Threads     []Thread     `json:"threads"`
+	Comments    []Comment    `json:"comments"`
+	Preferences []Preference `json:"preferences"`
+}
+
+// Thread is thread in backup.
+type Thread struct {
+	ID    int64  `json:"id"`
+	URI   string `json:"uri"`
+	Title string `json:"title"`
+}
+
+// Comment keep every column of comment, isso.Comment hide some of them in JSON.
+type Comment struct {
+	ID           int64    `json:"id"`
+	TID          int64    `json:"tid"`
+	Parent       *int64   `json:"parent"`
+	Created      float64  `json:"created"`
+	Modified     *float64 `json:"modified"`
+	Mode         int      `json:"mode"`
+	RemoteAddr   string   `json:"remote_addr"`
+	Text         string   `json:"text"`
+	Author       string   `json:"author"`
+	Email        *string  `json:"email"`
+	Website      *string  `json:"website"`
+	Likes        int      `json:"likes"`
+	Dislikes     int      `json:"dislikes"`
+	Voters       []byte   `json:"voters"`
+	Notification int      `json:"notification"`
+}
+
+// Preference value may be binary, such as cookie keys, so it is base64 encoded.
+type Preference struct {
+	Key   string `json:"key"`
+	Value []byte `json:"value"`
+}
+
+// Report is the summary of restore.
+type Report struct {
+	Threads     int
+	Comments    int
+	Preferences int
+	// Overwritten is the preferences already exist in target storage, such as
+	// hash-salt and cookie keys created by the first run.
+	Overwritten []string
+}
+
+// Export write every thread, comment and preference in storage to w.
+func Export(ctx context.Context, storage Storage, w io.Writer) error {
+	doc := Document{Version: Version, Created: time.Now().UTC()}
+
+	threads, err := storage.FetchThreads(ctx)
+	if err != nil {
+		return fmt.Errorf("fetch threads failed: %w", err)
+	}
+	doc.Threads = []Thread{}
+	for _, t := range threads {
+		doc.Threads = append(doc.Threads, Thread{ID: t.ID, URI: t.URI, Title: t.Title})
+	}
+
+	doc.Comments = []Comment{}
+	for offset := int64(0); ; offset += pageSize {
+		comments, err := storage.FetchComments(ctx, allModes, "id", true, pageSize, offset)
+		if err != nil {
+			return fmt.Errorf("fetch comments failed: %w", err)
+		}
+		for _, c := range comments {
+			doc.Comments = append(doc.Comments, fromComment(c))
+		}
+		if len(comments) < pageSize {
+			break
+		}
+	}
+
+	preferences, err := storage.FetchPreferences(ctx)
+	if err != nil {
+		return fmt.Errorf("fetch preferences failed: %w", err)
+	}
+	doc.Preferences = []Preference{}
+	for key, value := range preferences {
+		doc.Preferences = append(doc.Preferences, Preference{Key: key, Value: []byte(value)})
+	}
+	sort.Slice(doc.Preferences, func(i, j int) bool { return doc.Preferences[i].Key < doc.Preferences[j].Key })
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
+// Restore load the document in r into storage. Threads and comments keep their ID,
+// so storage must not have any thread. Preferences already exist are overwritten,
+// identicons and cookies depend on them. Restore is not atomic, run it in a
+// transaction so that a failed restore can be retried.
+func Restore(ctx context.Context, storage Storage, r io.Reader) (Report, error) {
+	var report Report
+	var doc Document
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return report, fmt.Errorf("parse backup failed: %w", err)
+	}
+	if doc.Version != Version {
+		return report, fmt.Errorf("not supported backup version %d, want %d", doc.Version, Version)
+	}
+
+	existing, err := storage.FetchThreads(ctx)
+	if err != nil {
+		return report, fmt.Errorf("fetch threads failed: %w", err)
+	}
+	if len(existing) != 0 {
+		return report, errors.New("restore need an empty database")
+	}
+
+	for _, t := range doc.Threads {
+		if err := storage.RestoreThread(ctx, isso.Thread{ID: t.ID, URI: t.URI, Title: t.Title}); err != nil {
+			return report, fmt.Errorf("restore thread %d failed: %w", t.ID, err)
+		}
+		report.Threads++
+	}
+	for _, c := range doc.Comments {
+		if err := storage.RestoreComment(ctx, c.toComment()); err != nil {
+			return report, fmt.Errorf("restore comment %d failed: %w", c.ID, err)
+		}
+		report.Comments++
+	}
+	for _, p := range doc.Preferences {
+		if p.Key == schemaVersionKey {
+			continue
+		}
+		_, err := storage.GetPreference(ctx, p.Key)
+		switch {
+		case err == nil:
+			err = storage.UpdatePreference(ctx, p.Key, string(p.Value))
+			report.Overwritten = append(report.Overwritten, p.Key)
+		case errors.Is(err, isso.ErrStorageNotFound):
+			err = storage.SetPreference(ctx, p.Key, string(p.Value))
+		default:
+			return report, fmt.Errorf("get preference %s failed: %w", p.Key, err)
+		}
+		if err != nil {
+			return report, fmt.Errorf("restore preference %s failed: %w", p.Key, err)
+		}
+		report.Preferences++
+	}
+	return report, nil
+}
+
+func fromComment(c isso.Comment) Comment {
+	return Comment{
+		ID:           c.ID,
+		TID:          c.TID,
+		Parent:       c.Parent,
+		Created:      c.Created,
+		Modified:     c.Modified,
+		Mode:         c.Mode,
+		RemoteAddr:   c.RemoteAddr,
+		Text:         c.Text,
+		Author:       c.Author,
+		Email:        c.Email,
+		Website:      c.Website,
+		Likes:        c.Likes,
+		Dislikes:     c.Dislikes,
+		Voters:       append([]byte(nil), c.Voters[:]...),
+		Notification: c.Notification,
+	}
+}
+
+func (c Comment) toComment() isso.Comment {
+	ic := isso.Comment{
+		ID:           c.ID,
+		TID:          c.TID,
+		Parent:       c.Parent,
+		Created:      c.Created,
+		Modified:     c.Modified,
+		Mode:         c.Mode,
+		RemoteAddr:   c.RemoteAddr,
+		Text:         c.Text,
+		Author:       c.Author,
+		Email:        c.Email,
+		Website:      c.Website,
+		Likes:        c.Likes,
+		Dislikes:     c.Dislikes,
+		Notification: c.Notification,
+	}
+	copy(ic.Voters[:], c.Voters)
+	return ic
+}
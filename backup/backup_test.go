@@ -0,0 +1,114 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"wrong.wang/x/go-isso/database"
+	"wrong.wang/x/go-isso/isso"
+)
+
+func TestExportRestore(t *testing.T) {
+	ctx := context.Background()
+	source, err := database.New("", time.Second)
+	if err != nil {
+		t.Fatalf("create source database failed: %v", err)
+	}
+	defer source.Close()
+
+	thread, _ := source.NewThread(ctx, "/post", "Post")
+	email := "a@example.com"
+	c1, _ := source.NewComment(ctx, isso.Comment{Text: "top", Author: "a", Email: &email, Mode: isso.ModeAccepted, Notification: 1}, thread.ID, "1.2.3.4")
+	c2, _ := source.NewComment(ctx, isso.Comment{Text: "reply", Author: "b", Mode: isso.ModeModeration, Parent: &c1.ID}, thread.ID, "1.2.3.5")
+	c3, _ := source.NewComment(ctx, isso.Comment{Text: "gone", Author: "c", Mode: isso.ModeAccepted}, thread.ID, "1.2.3.6")
+	// a deleted comment with reply is kept in database.
+	source.NewComment(ctx, isso.Comment{Text: "answer", Author: "d", Mode: isso.ModeAccepted, Parent: &c3.ID}, thread.ID, "1.2.3.7")
+	source.VoteComment(ctx, c1, true)
+	source.DeleteComment(ctx, c3.ID)
+	source.SetPreference(ctx, "session-key", "\xff\x00binary")
+	source.SetPreference(ctx, "hash-salt", "source")
+
+	var buf bytes.Buffer
+	if err := Export(ctx, source, &buf); err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+
+	target, err := database.New("", time.Second)
+	if err != nil {
+		t.Fatalf("create target database failed: %v", err)
+	}
+	defer target.Close()
+	target.SetPreference(ctx, "existing", "keep")
+	// created by the first run of go-isso.
+	target.SetPreference(ctx, "hash-salt", "target")
+
+	report, err := Restore(ctx, target, bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	if report.Threads != 1 || report.Comments != 4 || report.Preferences != 2 ||
+		len(report.Overwritten) != 1 || report.Overwritten[0] != "hash-salt" {
+		t.Errorf("Restore() report = %+v", report)
+	}
+
+	for _, id := range []int64{c1.ID, c2.ID, c3.ID} {
+		want, _ := source.GetComment(ctx, id)
+		got, err := target.GetComment(ctx, id)
+		if err != nil {
+			t.Fatalf("GetComment(%d) error = %v", id, err)
+		}
+		if got.TID != want.TID || got.Mode != want.Mode || got.RemoteAddr != want.RemoteAddr ||
+			got.Voters != want.Voters || got.Notification != want.Notification || got.Likes != want.Likes ||
+			got.Created != want.Created || got.Text != want.Text {
+			t.Errorf("restored comment = %+v, want %+v", got, want)
+		}
+		if (got.Parent == nil) != (want.Parent == nil) || (got.Parent != nil && *got.Parent != *want.Parent) {
+			t.Errorf("restored comment %d parent = %v, want %v", id, got.Parent, want.Parent)
+		}
+	}
+	if v, _ := target.GetPreference(ctx, "session-key"); v != "\xff\x00binary" {
+		t.Errorf("restored preference = %q", v)
+	}
+	if v, _ := target.GetPreference(ctx, "hash-salt"); v != "source" {
+		t.Errorf("overwritten preference = %q, want source", v)
+	}
+	if v, _ := target.GetPreference(ctx, "existing"); v != "keep" {
+		t.Errorf("existing preference = %q, want keep", v)
+	}
+
+	if _, err := Restore(ctx, target, bytes.NewReader(buf.Bytes())); err == nil {
+		t.Errorf("Restore() into non-empty database should fail")
+	}
+}
+
+func TestRestore_transaction(t *testing.T) {
+	ctx := context.Background()
+	target, err := database.New("", time.Second)
+	if err != nil {
+		t.Fatalf("create target database failed: %v", err)
+	}
+	defer target.Close()
+
+	// the second comment conflicts with the first one.
+	doc := `{"version": 1, "threads": [{"id": 1, "uri": "/post", "title": "Post"}],
+		"comments": [{"id": 1, "tid": 1, "text": "a"}, {"id": 1, "tid": 1, "text": "b"}]}`
+	err = target.Transaction(ctx, func(tx *database.Database) error {
+		_, err := Restore(ctx, tx, strings.NewReader(doc))
+		return err
+	})
+	if err == nil {
+		t.Fatalf("Restore() with conflicting comments should fail")
+	}
+
+	doc = strings.Replace(doc, `{"id": 1, "tid": 1, "text": "b"}`, `{"id": 2, "tid": 1, "text": "b"}`, 1)
+	err = target.Transaction(ctx, func(tx *database.Database) error {
+		_, err := Restore(ctx, tx, strings.NewReader(doc))
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Restore() after a failed one error = %v", err)
+	}
+}
@@ -0,0 +1,75 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"wrong.wang/x/go-isso/backup"
+	"wrong.wang/x/go-isso/config"
+	"wrong.wang/x/go-isso/database"
+	"wrong.wang/x/go-isso/logger"
+)
+
+func exportTo(cfg config.Config, args []string) {
+	if len(args) != 1 {
+		fmt.Printf("Usage of export:\n")
+		fmt.Printf("\tgo-isso -c <CONFIG PATH> export <PATH|->\n\n")
+		return
+	}
+
+	storage, err := database.New(cfg.DBPath, 10*time.Second)
+	if err != nil {
+		logger.Fatal("init database failed %v", err)
+	}
+	defer storage.Close()
+
+	var w io.Writer = os.Stdout
+	if args[0] != "-" {
+		f, err := os.Create(args[0])
+		if err != nil {
+			logger.Fatal("create %s failed: %v", args[0], err)
+		}
+		defer f.Close()
+		w = f
+	}
+	if err := backup.Export(context.Background(), storage, w); err != nil {
+		logger.Fatal("export failed: %v", err)
+	}
+}
+
+func restoreFrom(cfg config.Config, args []string) {
+	if len(args) != 1 {
+		fmt.Printf("Usage of restore:\n")
+		fmt.Printf("\tgo-isso -c <CONFIG PATH> restore <PATH>\n\n")
+		return
+	}
+
+	f, err := os.Open(args[0])
+	if err != nil {
+		logger.Fatal("open %s failed: %v", args[0], err)
+	}
+	defer f.Close()
+
+	storage, err := database.New(cfg.DBPath, 10*time.Second)
+	if err != nil {
+		logger.Fatal("init database failed %v", err)
+	}
+	defer storage.Close()
+
+	// a failed restore leaves the database empty, so it can be run again.
+	var report backup.Report
+	err = storage.Transaction(context.Background(), func(tx *database.Database) error {
+		report, err = backup.Restore(context.Background(), tx, f)
+		return err
+	})
+	if err != nil {
+		logger.Fatal("restore failed: %v", err)
+	}
+	fmt.Printf("restored %d threads, %d comments, %d preferences\n", report.Threads, report.Comments, report.Preferences)
+	for _, key := range report.Overwritten {
+		fmt.Printf("preference %s already exists, overwritten\n", key)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"runtime"
+	"strings"
 
 	"wrong.wang/x/go-isso/config"
 	"wrong.wang/x/go-isso/logger"
@@ -14,17 +15,17 @@ import (
 // Parse parses command line arguments
 func Parse() {
 	var (
-		flagVersion        bool
-		flagDebug          bool
-		flagConfigFilePath string
+		flagVersion         bool
+		flagDebug           bool
+		flagConfigFilePaths configPaths
 	)
 	flag.Usage = func() {
 		fmt.Printf("Usage of %s:\n", os.Args[0])
-		fmt.Printf("\tgo-isso [-v] -c <CONFIG PATH> [import|run] \n\n")
+		fmt.Printf("\tgo-isso [-v] -c <CONFIG PATH> [-c <CONFIG PATH>...] [import|export|restore|migrate|rotate-keys|run] [ARGS]\n\n")
 		flag.PrintDefaults()
 	}
 
-	flag.StringVar(&flagConfigFilePath, "c", "", "Load configuration file")
+	flag.Var(&flagConfigFilePaths, "c", "Load configuration file, repeat it to run multiple websites")
 	flag.BoolVar(&flagVersion, "v", false, "Show application version")
 	flag.BoolVar(&flagDebug, "d", false, "turn on debug mode")
 	flag.Parse()
@@ -39,7 +40,7 @@ func Parse() {
 		return
 	}
 
-	if flagConfigFilePath == "" {
+	if len(flagConfigFilePaths) == 0 {
 		fmt.Printf("must specify configuration file\n\n")
 		flag.Usage()
 		return
@@ -49,9 +50,18 @@ func Parse() {
 		logger.EnableDebug()
 	}
 
-	cfg, err := config.Parse(flagConfigFilePath)
+	cfgs, err := parseConfigs(flagConfigFilePaths)
 	if err != nil {
-		logger.Fatal("can not read config file: %v", err)
+		logger.Fatal("%v", err)
+	}
+	// process wide settings come from the first config.
+	cfg := &cfgs[0]
+	switch cfg.LogFormat {
+	case "", "text":
+	case "json":
+		logger.EnableJSON()
+	default:
+		logger.Fatal("unknown log-format %q, want text or json", cfg.LogFormat)
 	}
 	if cfg.LogFilePath != "" {
 		logFile, err := os.Create(cfg.LogFilePath)
@@ -63,19 +73,58 @@ func Parse() {
 		defer logFile.Close()
 	}
 
-	if flag.NArg() != 1 {
-		fmt.Printf("need one and only one argument to spectify action.\n\n")
+	if flag.NArg() < 1 {
+		fmt.Printf("need one argument to spectify action.\n\n")
+		flag.Usage()
+		return
+	}
+
+	action := flag.Arg(0)
+	if action != "run" && len(cfgs) > 1 {
+		fmt.Printf("%s works on one website, specify only one configuration file\n\n", action)
 		flag.Usage()
 		return
 	}
 
-	switch action := flag.Arg(0); action {
+	switch action {
 	case "import":
-		importFrom()
+		importFrom(*cfg, flag.Args()[1:])
+	case "export":
+		exportTo(*cfg, flag.Args()[1:])
+	case "restore":
+		restoreFrom(*cfg, flag.Args()[1:])
+	case "migrate":
+		migrate(*cfg, flag.Args()[1:])
+	case "rotate-keys":
+		rotateKeys(*cfg, flag.Args()[1:])
 	case "run":
-		startDaemon(*cfg)
+		startDaemon(flagConfigFilePaths, cfgs)
 	default:
 		fmt.Printf("%s is not supported action argument \n\n", action)
 		flag.Usage()
 	}
 }
+
+// configPaths collect values of repeated `-c`.
+type configPaths []string
+
+func (c *configPaths) String() string {
+	return strings.Join(*c, ",")
+}
+
+func (c *configPaths) Set(value string) error {
+	*c = append(*c, value)
+	return nil
+}
+
+func parseConfigs(paths []string) ([]config.Config, error) {
+	cfgs := make([]config.Config, 0, len(paths))
+	for _, path := range paths {
+		cfg, err := config.Parse(path)
+		if err != nil {
+			return nil, fmt.Errorf("can not read config file %s: %v", path, err)
+		}
+		cfgs = append(cfgs, *cfg)
+	}
+	return cfgs, nil
+}
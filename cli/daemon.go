@@ -2,10 +2,8 @@ package cli
 
 import (
 	"context"
-	"net/http"
 	"os"
 	"os/signal"
-	"runtime"
 	"syscall"
 	"time"
 
@@ -14,20 +12,35 @@ import (
 	"wrong.wang/x/go-isso/server"
 )
 
-func startDaemon(cfg config.Config) {
+// startDaemon serve websites of cfgs, which are parsed from paths,
+// and parse paths again on SIGHUP to reload config.
+func startDaemon(paths []string, cfgs []config.Config) {
 	logger.Info("Starting go-isso...")
 
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, os.Interrupt)
 	signal.Notify(stop, syscall.SIGTERM)
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
 
 	go showProcessStatistics()
 
-	var httpServer *http.Server
-
-	httpServer = server.Serve(cfg)
-
-	<-stop
+	httpServer := server.Serve(cfgs)
+
+	for waiting := true; waiting; {
+		select {
+		case <-reload:
+			logger.Info("Reloading...")
+			cfgs, err := parseConfigs(paths)
+			if err != nil {
+				logger.Error("reload failed, keep the old config: %v", err)
+				continue
+			}
+			httpServer.Reload(cfgs)
+		case <-stop:
+			waiting = false
+		}
+	}
 	logger.Info("Shutting down the process...")
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -41,11 +54,9 @@ func startDaemon(cfg config.Config) {
 
 func showProcessStatistics() {
 	for {
-		var m runtime.MemStats
-		runtime.ReadMemStats(&m)
+		s := server.ReadProcessStatistics()
 		logger.Debug("Sys=%vK, InUse=%vK, HeapInUse=%vK, StackSys=%vK, StackInUse=%vK, GoRoutines=%d, NumCPU=%d",
-			m.Sys/1024, (m.Sys-m.HeapReleased)/1024, m.HeapInuse/1024, m.StackSys/1024, m.StackInuse/1024,
-			runtime.NumGoroutine(), runtime.NumCPU())
+			s.SysKB, s.InUseKB, s.HeapInUseKB, s.StackSysKB, s.StackInUseKB, s.Goroutines, s.NumCPU)
 		time.Sleep(30 * time.Second)
 	}
 }
@@ -1,9 +1,104 @@
 package cli
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"wrong.wang/x/go-isso/config"
+	"wrong.wang/x/go-isso/database"
+	"wrong.wang/x/go-isso/importer"
 	"wrong.wang/x/go-isso/logger"
 )
 
-func importFrom() {
-	logger.Error("work in process")
+func importFrom(cfg config.Config, args []string) {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	dryRun := fs.Bool("dry-run", false, "only report what would be imported")
+	format := fs.String("format", "isso", "format of the file to import: isso, disqus or wordpress")
+	fs.Usage = func() {
+		fmt.Printf("Usage of import:\n")
+		fmt.Printf("\tgo-isso -c <CONFIG PATH> import [-dry-run] [-format isso|disqus|wordpress] <PATH>\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return
+	}
+
+	threads, err := readThreads(*format, fs.Arg(0))
+	if err != nil {
+		logger.Fatal("read %s failed: %v", fs.Arg(0), err)
+	}
+
+	if *dryRun {
+		storage := openForDryRun(cfg.DBPath)
+		defer storage.Close()
+		report, err := importer.Save(context.Background(), storage, threads, true)
+		if err != nil {
+			logger.Fatal("import failed: %v", err)
+		}
+		report.Print(os.Stdout, true)
+		return
+	}
+
+	storage, err := database.New(cfg.DBPath, 10*time.Second)
+	if err != nil {
+		logger.Fatal("init database failed %v", err)
+	}
+	defer storage.Close()
+
+	// a failed import leaves nothing behind, so it can be run again.
+	var report importer.Report
+	err = storage.Transaction(context.Background(), func(tx *database.Database) error {
+		report, err = importer.Save(context.Background(), tx, threads, false)
+		return err
+	})
+	if err != nil {
+		logger.Fatal("import failed: %v", err)
+	}
+	report.Print(os.Stdout, false)
+}
+
+// openForDryRun open the database read only, a database not created yet is
+// the same as an empty one, which lives in memory.
+func openForDryRun(path string) *database.Database {
+	storage, err := database.OpenReadOnly(path, 10*time.Second)
+	if path == "" || errors.Is(err, os.ErrNotExist) {
+		storage, err = database.New("", 10*time.Second)
+	}
+	if err != nil {
+		logger.Fatal("init database failed %v", err)
+	}
+	pending, err := storage.Migrate(context.Background(), true)
+	if err != nil {
+		logger.Fatal("get migrations failed: %v", err)
+	}
+	if len(pending) > 0 {
+		logger.Fatal("database has %d pending migrations, run `migrate` first", len(pending))
+	}
+	return storage
+}
+
+func readThreads(format string, path string) ([]importer.Thread, error) {
+	if format == "isso" {
+		return importer.FromISSO(path)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	switch format {
+	case "disqus":
+		return importer.FromDisqus(f)
+	case "wordpress":
+		return importer.FromWordPress(f)
+	default:
+		return nil, fmt.Errorf("not supported import format %s", format)
+	}
 }
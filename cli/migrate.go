@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"time"
+
+	"wrong.wang/x/go-isso/config"
+	"wrong.wang/x/go-isso/database"
+	"wrong.wang/x/go-isso/logger"
+)
+
+func migrate(cfg config.Config, args []string) {
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	dryRun := fs.Bool("dry-run", false, "only show migrations would be applied")
+	fs.Usage = func() {
+		fmt.Printf("Usage of migrate:\n")
+		fmt.Printf("\tgo-isso -c <CONFIG PATH> migrate [-dry-run] [status]\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() > 1 || (fs.NArg() == 1 && fs.Arg(0) != "status") {
+		fs.Usage()
+		return
+	}
+
+	ctx := context.Background()
+	storage, err := database.Open(cfg.DBPath, 10*time.Second)
+	if err != nil {
+		logger.Fatal("init database failed %v", err)
+	}
+	defer storage.Close()
+
+	if fs.Arg(0) == "status" {
+		version, err := storage.SchemaVersion(ctx)
+		if err != nil {
+			logger.Fatal("get schema version failed: %v", err)
+		}
+		steps, err := storage.Migrations(ctx)
+		if err != nil {
+			logger.Fatal("get migrations failed: %v", err)
+		}
+		fmt.Printf("schema version %d, latest %d\n", version, len(steps))
+		for _, m := range steps {
+			status := "pending"
+			if m.Applied {
+				status = "applied"
+			}
+			fmt.Printf("%4d  %-30s %s\n", m.Version, m.Name, status)
+		}
+		return
+	}
+
+	applied, err := storage.Migrate(ctx, *dryRun)
+	if err != nil {
+		logger.Fatal("migrate failed: %v", err)
+	}
+	verb := "applied"
+	if *dryRun {
+		verb = "would apply"
+	}
+	if len(applied) == 0 {
+		fmt.Printf("schema is up to date\n")
+	}
+	for _, m := range applied {
+		fmt.Printf("%s %d %s\n", verb, m.Version, m.Name)
+	}
+}
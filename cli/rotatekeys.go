@@ -0,0 +1,39 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"time"
+
+	"wrong.wang/x/go-isso/config"
+	"wrong.wang/x/go-isso/database"
+	"wrong.wang/x/go-isso/isso"
+	"wrong.wang/x/go-isso/logger"
+)
+
+func rotateKeys(cfg config.Config, args []string) {
+	fs := flag.NewFlagSet("rotate-keys", flag.ExitOnError)
+	purge := fs.Bool("purge", false, "drop old keys, all existing cookies and links become invalid")
+	fs.Usage = func() {
+		fmt.Printf("Usage of rotate-keys:\n")
+		fmt.Printf("\tgo-isso -c <CONFIG PATH> rotate-keys [-purge]\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return
+	}
+
+	storage, err := database.New(cfg.DBPath, 10*time.Second)
+	if err != nil {
+		logger.Fatal("init database failed %v", err)
+	}
+	defer storage.Close()
+
+	if err := isso.RotateKeys(context.Background(), storage, cfg.MaxAge, *purge); err != nil {
+		logger.Fatal("rotate keys failed: %v", err)
+	}
+	fmt.Printf("cookie keys rotated, restart go-isso to sign with the new keys\n")
+}
@@ -10,18 +10,26 @@ type Config struct {
 	Notify             []string `ini:"notify"`
 	ReplyNotifications bool     `ini:"reply-notifications"`
 	LogFilePath        string   `ini:"log-file"`
+	LogFormat          string   `ini:"log-format"`
 	Gravatar           bool     `ini:"gravatar"`
 	GravatarURL        string   `ini:"gravatar-url"`
+	LatestEnabled      bool     `ini:"latest-enabled"`
 	Server             Server
 	Admin              Admin
 	Moderation         Moderation
 	SMTP               SMTP
+	Hash               Hash
 }
 
 // Server store all HTTP server related config
 type Server struct {
-	Listen         string `ini:"listen"`
-	PublicEndpoint string `ini:"public-endpoint"`
+	Listen         []string `ini:"listen"`
+	CertFile       string   `ini:"cert-file"`
+	KeyFile        string   `ini:"key-file"`
+	PublicEndpoint string   `ini:"public-endpoint"`
+	TrustedProxies []string `ini:"trusted-proxies"`
+	Profile        string   `ini:"profile"`
+	Metrics        string   `ini:"metrics"`
 	Guard          Guard
 }
 
@@ -66,3 +74,9 @@ type SMTP struct {
 	From     string `ini:"from"`
 	Timeout  int    `ini:"timeout"`
 }
+
+// Hash config how to hash email or IP address of commenter
+type Hash struct {
+	Salt      string `ini:"salt"`
+	Algorithm string `ini:"algorithm"`
+}
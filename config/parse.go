@@ -25,6 +25,7 @@ func Parse(configPath string) (*Config, error) {
 	}
 	DurMaxAge, err := time.ParseDuration(INIConfig.Section("general").Key("max-age").MustString("1m"))
 	mc.MaxAge = int(DurMaxAge.Seconds())
+	mc.GravatarURL = INIConfig.Section("general").Key("gravatar-url").MustString("https://www.gravatar.com/avatar/{}?d=identicon")
 
 	splitStringtoStrings(&mc.Host, "\n")
 	splitStringtoStrings(&mc.Notify, ",")
@@ -54,6 +55,10 @@ func Parse(configPath string) (*Config, error) {
 	if err != nil {
 		return nil, err
 	}
+	err = INIConfig.Section("hash").MapTo(&mc.Hash)
+	if err != nil {
+		return nil, err
+	}
 	logger.Debug(fmt.Sprintf("%# v", pretty.Formatter(mc)))
 	return &mc, err
 }
@@ -0,0 +1,56 @@
+package config
+
+import "reflect"
+
+// Reload return next with settings which can not change without restart
+// kept from current, and names of those changed. Everything else, like
+// hosts, guard, moderation, markup and smtp, takes effect at once.
+func Reload(current, next Config) (Config, []string) {
+	var changed []string
+	if current.DBPath != next.DBPath {
+		changed = append(changed, "dbpath")
+		next.DBPath = current.DBPath
+	}
+	if current.Name != next.Name {
+		changed = append(changed, "name")
+		next.Name = current.Name
+	}
+	// cookie keys verify values up to max-age, they are loaded at startup.
+	if current.MaxAge != next.MaxAge {
+		changed = append(changed, "max-age")
+		next.MaxAge = current.MaxAge
+	}
+	if !reflect.DeepEqual(current.Notify, next.Notify) {
+		changed = append(changed, "notify")
+		next.Notify = current.Notify
+	}
+	if current.LogFilePath != next.LogFilePath {
+		changed = append(changed, "log-file")
+		next.LogFilePath = current.LogFilePath
+	}
+	if current.LogFormat != next.LogFormat {
+		changed = append(changed, "log-format")
+		next.LogFormat = current.LogFormat
+	}
+	if !reflect.DeepEqual(current.Server.Listen, next.Server.Listen) {
+		changed = append(changed, "[server] listen")
+		next.Server.Listen = current.Server.Listen
+	}
+	if current.Server.CertFile != next.Server.CertFile || current.Server.KeyFile != next.Server.KeyFile {
+		changed = append(changed, "[server] cert-file/key-file")
+		next.Server.CertFile, next.Server.KeyFile = current.Server.CertFile, current.Server.KeyFile
+	}
+	if current.Server.Profile != next.Server.Profile {
+		changed = append(changed, "[server] profile")
+		next.Server.Profile = current.Server.Profile
+	}
+	if current.Server.Metrics != next.Server.Metrics {
+		changed = append(changed, "[server] metrics")
+		next.Server.Metrics = current.Server.Metrics
+	}
+	if current.Hash != next.Hash {
+		changed = append(changed, "[hash]")
+		next.Hash = current.Hash
+	}
+	return next, changed
+}
@@ -0,0 +1,80 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestReload(t *testing.T) {
+	current := Config{
+		DBPath:      "/var/lib/isso/comments.db",
+		Name:        "blog",
+		Host:        []string{"https://example.com"},
+		MaxAge:      900,
+		Notify:      []string{"smtp"},
+		LogFilePath: "/var/log/isso.log",
+		LogFormat:   "json",
+		Server: Server{
+			Listen:   []string{"http://localhost:8080"},
+			CertFile: "cert.pem",
+			KeyFile:  "key.pem",
+			Profile:  "on",
+			Metrics:  "on",
+		},
+		Hash: Hash{Salt: "salt", Algorithm: "pbkdf2"},
+	}
+	tests := []struct {
+		name        string
+		change      func(c *Config)
+		wantChanged []string
+		// live apply the changes expected to take effect, nil when there is none.
+		live func(c *Config)
+	}{
+		{"nothing", func(c *Config) {}, nil, nil},
+		{"dbpath", func(c *Config) { c.DBPath = "/tmp/comments.db" }, []string{"dbpath"}, nil},
+		{"name", func(c *Config) { c.Name = "wiki" }, []string{"name"}, nil},
+		{"max-age", func(c *Config) { c.MaxAge = 60 }, []string{"max-age"}, nil},
+		{"notify", func(c *Config) { c.Notify = []string{"stdout"} }, []string{"notify"}, nil},
+		{"log-file", func(c *Config) { c.LogFilePath = "" }, []string{"log-file"}, nil},
+		{"log-format", func(c *Config) { c.LogFormat = "text" }, []string{"log-format"}, nil},
+		{"listen", func(c *Config) { c.Server.Listen = []string{"http://localhost:8081"} },
+			[]string{"[server] listen"}, nil},
+		{"key-file", func(c *Config) { c.Server.KeyFile = "new.pem" }, []string{"[server] cert-file/key-file"}, nil},
+		{"profile", func(c *Config) { c.Server.Profile = "off" }, []string{"[server] profile"}, nil},
+		{"metrics", func(c *Config) { c.Server.Metrics = "off" }, []string{"[server] metrics"}, nil},
+		{"hash", func(c *Config) { c.Hash.Salt = "pepper" }, []string{"[hash]"}, nil},
+		{"live", func(c *Config) {
+			c.Host = []string{"https://example.org"}
+			c.Server.PublicEndpoint = "https://isso.example.org"
+			c.Moderation.Enable = true
+			c.SMTP.Host = "smtp.example.org"
+		}, nil, func(c *Config) {
+			c.Host = []string{"https://example.org"}
+			c.Server.PublicEndpoint = "https://isso.example.org"
+			c.Moderation.Enable = true
+			c.SMTP.Host = "smtp.example.org"
+		}},
+		{"mixed", func(c *Config) {
+			c.DBPath = "/tmp/comments.db"
+			c.Hash.Algorithm = "sha1"
+			c.Admin.Enable = true
+		}, []string{"dbpath", "[hash]"}, func(c *Config) { c.Admin.Enable = true }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := current
+			tt.change(&next)
+			want := current
+			if tt.live != nil {
+				tt.live(&want)
+			}
+			got, changed := Reload(current, next)
+			if !reflect.DeepEqual(changed, tt.wantChanged) {
+				t.Errorf("Reload() changed = %v, want %v", changed, tt.wantChanged)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Reload() got %+v, want %+v", got, want)
+			}
+		})
+	}
+}
@@ -13,14 +13,14 @@ import (
 
 // IsApprovedAuthor check if email has approved in 6 month
 func (d *Database) IsApprovedAuthor(ctx context.Context, email string) bool {
-	logger.Debug("email %s", email)
+	logger.FromContext(ctx).Debug("email %s", email)
 	ctx, cancel := d.withTimeout(ctx)
 	defer cancel()
 	if email == "" {
 		return false
 	}
 	var flag int64
-	err := d.DB.QueryRowContext(ctx, d.statement["comment_is_previously_approved_author"], email).Scan(&flag)
+	err := d.conn.QueryRowContext(ctx, d.statement["comment_is_previously_approved_author"], email).Scan(&flag)
 	return (err == nil) && (flag == 1)
 }
 
@@ -28,7 +28,7 @@ func (d *Database) IsApprovedAuthor(ctx context.Context, email string) bool {
 func (d *Database) NewComment(ctx context.Context, c isso.Comment, threadID int64, remoteAddr string) (isso.Comment, error) {
 	ctx, cancel := d.withTimeout(ctx)
 	defer cancel()
-	logger.Debug("create %s 's comment at %d", c.Author, threadID)
+	logger.FromContext(ctx).Debug("create %s 's comment at %d", c.Author, threadID)
 	if c.Parent != nil {
 		parent, err := d.getComment(ctx, *c.Parent)
 		if err != nil {
@@ -47,26 +47,66 @@ func (d *Database) NewComment(ctx context.Context, c isso.Comment, threadID int6
 
 	nc := newNullComment(c, threadID, remoteAddr)
 
-	result, err := d.DB.ExecContext(ctx, d.statement["comment_new"], nc.TID, nc.Parent, nc.Created,
+	var id int64
+	err := d.execstmt(ctx, nil, &id, d.statement["comment_new"], nc.TID, nc.Parent, nc.Created,
 		nc.Modified, nc.Mode, nc.RemoteAddr, nc.Text, nc.Author, nc.Email, nc.Website, nc.Voters, nc.Notification,
 	)
 	if err != nil {
 		return isso.Comment{}, wraperror(err)
 	}
-	id, err := result.LastInsertId()
+	comment, err := d.GetComment(ctx, id)
 	if err != nil {
 		return isso.Comment{}, wraperror(err)
 	}
-	comment, err := d.GetComment(ctx, id)
+	return comment, nil
+}
+
+// ImportComment add comment into database without any change
+func (d *Database) ImportComment(ctx context.Context, c isso.Comment) (isso.Comment, error) {
+	ctx, cancel := d.withTimeout(ctx)
+	defer cancel()
+	logger.FromContext(ctx).Debug("import %s 's comment at %d", c.Author, c.TID)
+
+	voters := make([]byte, 256)
+	copy(voters, c.Voters[:])
+
+	var lastinsertid int64
+	err := d.execstmt(ctx, nil, &lastinsertid, d.statement["comment_import"], c.TID, null.IntFromPtr(c.Parent),
+		c.Created, null.FloatFromPtr(c.Modified), c.Mode, c.RemoteAddr, c.Text, c.Author,
+		null.StringFromPtr(c.Email), null.StringFromPtr(c.Website), c.Likes, c.Dislikes, voters, c.Notification,
+	)
+	if err != nil {
+		return isso.Comment{}, wraperror(err)
+	}
+	comment, err := d.GetComment(ctx, lastinsertid)
 	if err != nil {
 		return isso.Comment{}, wraperror(err)
 	}
 	return comment, nil
 }
 
+// RestoreComment add comment into database with its id
+func (d *Database) RestoreComment(ctx context.Context, c isso.Comment) error {
+	ctx, cancel := d.withTimeout(ctx)
+	defer cancel()
+	logger.FromContext(ctx).Debug("restore comment %d", c.ID)
+
+	voters := make([]byte, 256)
+	copy(voters, c.Voters[:])
+
+	err := d.execstmt(ctx, nil, nil, d.statement["comment_restore"], c.TID, c.ID, null.IntFromPtr(c.Parent),
+		c.Created, null.FloatFromPtr(c.Modified), c.Mode, c.RemoteAddr, c.Text, c.Author,
+		null.StringFromPtr(c.Email), null.StringFromPtr(c.Website), c.Likes, c.Dislikes, voters, c.Notification,
+	)
+	if err != nil {
+		return wraperror(err)
+	}
+	return nil
+}
+
 // GetComment get comment by ID
 func (d *Database) GetComment(ctx context.Context, id int64) (isso.Comment, error) {
-	logger.Debug("get comment %d", id)
+	logger.FromContext(ctx).Debug("get comment %d", id)
 	nc, err := d.getComment(ctx, id)
 	if err != nil {
 		return isso.Comment{}, wraperror(err)
@@ -80,7 +120,7 @@ func (d *Database) getComment(ctx context.Context, id int64) (nullComment, error
 
 	var nc nullComment
 	voters := make([]byte, 256)
-	err := d.DB.QueryRowContext(ctx, d.statement["comment_get_by_id"], id).Scan(
+	err := d.conn.QueryRowContext(ctx, d.statement["comment_get_by_id"], id).Scan(
 		&nc.TID, &nc.ID, &nc.Parent, &nc.Created, &nc.Modified, &nc.Mode,
 		&nc.RemoteAddr, &nc.Text, &nc.Author, &nc.Email, &nc.Website, &nc.Likes,
 		&nc.Dislikes, &voters, &nc.Notification,
@@ -95,13 +135,13 @@ func (d *Database) getComment(ctx context.Context, id int64) (nullComment, error
 // CountReply return comment count for main thread's comment and all reply threads for one uri.
 // 0 mean null parent
 func (d *Database) CountReply(ctx context.Context, uri string, mode int, after float64) (map[int64]int64, error) {
-	logger.Debug("uri: %s", uri)
+	logger.FromContext(ctx).Debug("uri: %s", uri)
 	ctx, cancel := d.withTimeout(ctx)
 	defer cancel()
 
 	counts := map[int64]int64{}
 
-	rows, err := d.DB.QueryContext(ctx, d.statement["comment_count_reply"], uri, mode, mode, after)
+	rows, err := d.conn.QueryContext(ctx, d.statement["comment_count_reply"], uri, mode, mode, after)
 	if err != nil {
 		return nil, wraperror(err)
 	}
@@ -127,7 +167,7 @@ func (d *Database) CountReply(ctx context.Context, uri string, mode int, after f
 
 // FetchCommentsByURI fetch comments related uri with a lot of param
 func (d *Database) FetchCommentsByURI(ctx context.Context, uri string, parent int64, mode int, orderBy string, asc bool) (map[int64][]isso.Comment, error) {
-	logger.Debug("uri: %s", uri)
+	logger.FromContext(ctx).Debug("uri: %s", uri)
 	ctx, cancel := d.withTimeout(ctx)
 	defer cancel()
 
@@ -149,13 +189,13 @@ func (d *Database) FetchCommentsByURI(ctx context.Context, uri string, parent in
 	switch {
 	case parent < 0:
 		stmt := d.statement["comment_fetch_by_uri"] + condition
-		rows, err = d.DB.QueryContext(ctx, stmt, uri, mode, mode)
+		rows, err = d.conn.QueryContext(ctx, stmt, uri, mode, mode)
 	case parent == 0:
 		stmt := d.statement["comment_fetch_by_uri"] + ` AND comments.parent IS NULL ` + condition
-		rows, err = d.DB.QueryContext(ctx, stmt, uri, mode, mode)
+		rows, err = d.conn.QueryContext(ctx, stmt, uri, mode, mode)
 	case parent > 0:
-		stmt := d.statement["comment_fetch_by_uri"] + ` AND comments.parent=? ` + condition
-		rows, err = d.DB.QueryContext(ctx, stmt, uri, mode, mode, parent)
+		stmt := d.statement["comment_fetch_by_uri_and_parent"] + condition
+		rows, err = d.conn.QueryContext(ctx, stmt, uri, mode, mode, parent)
 	}
 
 	defer rows.Close()
@@ -189,49 +229,130 @@ func (d *Database) FetchCommentsByURI(ctx context.Context, uri string, parent in
 	return commentsbyparent, nil
 }
 
-// CountComment count comment per thread
+// CountComment count public comment of threads, uris without thread count 0
 func (d *Database) CountComment(ctx context.Context, uris []string) (map[string]int64, error) {
 	ctx, cancel := d.withTimeout(ctx)
 	defer cancel()
-	logger.Debug("uris: %v", uris)
+	logger.FromContext(ctx).Debug("uris: %v", uris)
 	commentByURI := map[string]int64{}
-	if len(uris) == 0 {
-		return commentByURI, nil
+	for _, uri := range uris {
+		commentByURI[uri] = 0
+	}
+	unique := make([]interface{}, 0, len(commentByURI))
+	for uri := range commentByURI {
+		unique = append(unique, uri)
 	}
-	rows, err := d.DB.QueryContext(ctx, d.statement["comment_count"])
-	defer rows.Close()
 
+	// keep bind parameters under the limit of SQLite.
+	const batch = 500
+	for len(unique) > 0 {
+		n := len(unique)
+		if n > batch {
+			n = batch
+		}
+		if err := d.countComment(ctx, unique[:n], commentByURI); err != nil {
+			return nil, wraperror(err)
+		}
+		unique = unique[n:]
+	}
+	return commentByURI, nil
+}
+
+func (d *Database) countComment(ctx context.Context, uris []interface{}, commentByURI map[string]int64) error {
+	stmt := fmt.Sprintf(d.statement["comment_count"], d.placeholders(1, len(uris)))
+	rows, err := d.conn.QueryContext(ctx, stmt, uris...)
 	if err != nil {
-		return nil, wraperror(err)
+		return err
 	}
+	defer rows.Close()
 
 	for rows.Next() {
 		var uri string
 		var count int64
-		err := rows.Scan(&uri, &count)
-		if err != nil {
-			return nil, wraperror(err)
+		if err := rows.Scan(&uri, &count); err != nil {
+			return err
 		}
 		commentByURI[uri] = count
 	}
+	return rows.Err()
+}
+
+// FetchComments fetch comments of all threads with mode, sorted and paginated.
+func (d *Database) FetchComments(ctx context.Context, mode int, orderBy string, asc bool, limit int64, offset int64) ([]isso.Comment, error) {
+	logger.FromContext(ctx).Debug("mode: %d, limit: %d, offset: %d", mode, limit, offset)
+	ctx, cancel := d.withTimeout(ctx)
+	defer cancel()
+
+	switch orderBy {
+	case "id", "created", "modified", "likes", "dislikes", "tid":
+	default:
+		orderBy = "id"
+	}
 
+	desc := ""
+	if !asc {
+		desc += ` DESC `
+	}
+
+	stmt := fmt.Sprintf(d.statement["comment_fetch_all"], orderBy+desc)
+	rows, err := d.conn.QueryContext(ctx, stmt, mode, mode, limit, offset)
+	if err != nil {
+		return nil, wraperror(err)
+	}
+	defer rows.Close()
+
+	comments := []isso.Comment{}
+	for rows.Next() {
+		var nc nullComment
+		err := rows.Scan(
+			&nc.TID, &nc.ID, &nc.Parent, &nc.Created, &nc.Modified, &nc.Mode,
+			&nc.RemoteAddr, &nc.Text, &nc.Author, &nc.Email, &nc.Website, &nc.Likes,
+			&nc.Dislikes, &nc.Voters, &nc.Notification,
+		)
+		if err != nil {
+			return nil, wraperror(err)
+		}
+		comments = append(comments, nc.ToComment())
+	}
 	err = rows.Err()
 	if err != nil {
 		return nil, wraperror(err)
 	}
+	return comments, nil
+}
 
-	uriMap := map[string]int64{}
-	for _, uri := range uris {
-		uriMap[uri] = commentByURI[uri]
+// CountCommentsByMode count all comments group by mode
+func (d *Database) CountCommentsByMode(ctx context.Context) (map[int]int64, error) {
+	ctx, cancel := d.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := d.conn.QueryContext(ctx, d.statement["comment_count_by_mode"])
+	if err != nil {
+		return nil, wraperror(err)
+	}
+	defer rows.Close()
+
+	counts := map[int]int64{}
+	for rows.Next() {
+		var mode int
+		var count int64
+		if err := rows.Scan(&mode, &count); err != nil {
+			return nil, wraperror(err)
+		}
+		counts[mode] = count
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, wraperror(err)
 	}
-	return uriMap, nil
+	return counts, nil
 }
 
 // ActivateComment Activate comment id if pending
 func (d *Database) ActivateComment(ctx context.Context, id int64) error {
 	ctx, cancel := d.withTimeout(ctx)
 	defer cancel()
-	logger.Debug("id: %d", id)
+	logger.FromContext(ctx).Debug("id: %d", id)
 
 	var rowsaffected int64
 	err := d.execstmt(ctx, &rowsaffected, nil, d.statement["comment_activate"], id)
@@ -248,7 +369,7 @@ func (d *Database) ActivateComment(ctx context.Context, id int64) error {
 func (d *Database) EditComment(ctx context.Context, c isso.Comment) (isso.Comment, error) {
 	ctx, cancel := d.withTimeout(ctx)
 	defer cancel()
-	logger.Debug("edit %s 's comment", c.Author)
+	logger.FromContext(ctx).Debug("edit %s 's comment", c.Author)
 
 	var rowsaffected int64
 	if c.Modified == nil {
@@ -270,15 +391,28 @@ func (d *Database) EditComment(ctx context.Context, c isso.Comment) (isso.Commen
 	return comment, nil
 }
 
+// UnsubscribeComment turn off notification for email on comment id and its replies
+func (d *Database) UnsubscribeComment(ctx context.Context, email string, id int64) error {
+	ctx, cancel := d.withTimeout(ctx)
+	defer cancel()
+	logger.FromContext(ctx).Debug("unsubscribe %s from comment %d", email, id)
+
+	err := d.execstmt(ctx, nil, nil, d.statement["comment_unsubscribe"], email, id)
+	if err != nil {
+		return wraperror(err)
+	}
+	return nil
+}
+
 // DeleteComment delete comment by id
 func (d *Database) DeleteComment(ctx context.Context, cid int64) (isso.Comment, error) {
 	ctx, cancel := d.withTimeout(ctx)
 	defer cancel()
-	logger.Debug("delete comment %d", cid)
+	logger.FromContext(ctx).Debug("delete comment %d", cid)
 
 	var n int64
 	var err error
-	if err = d.DB.QueryRowContext(ctx, d.statement["comment_delete_check"], cid).Scan(&n); err == nil {
+	if err = d.conn.QueryRowContext(ctx, d.statement["comment_delete_check"], cid).Scan(&n); err == nil {
 		stmt := d.statement["comment_delete_hard"]
 		if n > 0 {
 			stmt = d.statement["comment_delete_soft"]
@@ -299,7 +433,7 @@ func (d *Database) DeleteComment(ctx context.Context, cid int64) (isso.Comment,
 func (d *Database) VoteComment(ctx context.Context, c isso.Comment, up bool) error {
 	ctx, cancel := d.withTimeout(ctx)
 	defer cancel()
-	logger.Debug("vote comment %d", c.ID)
+	logger.FromContext(ctx).Debug("vote comment %d", c.ID)
 
 	if up {
 		c.Likes++
@@ -3,6 +3,7 @@ package database
 import (
 	"context"
 	"errors"
+	"fmt"
 	"reflect"
 	"testing"
 
@@ -48,3 +49,119 @@ func TestDatabase_GetComment(t *testing.T) {
 		}
 	})
 }
+
+func TestDatabase_ActivateComment(t *testing.T) {
+	thread, err := db.NewThread(context.Background(), "/activate", "activate")
+	if err != nil {
+		t.Fatalf("Database.NewThread() error = %v", err)
+	}
+	c, err := db.NewComment(context.Background(),
+		isso.Comment{Text: "pending", Author: "a", Mode: isso.ModeModeration}, thread.ID, "127.0.0.1")
+	if err != nil {
+		t.Fatalf("Database.NewComment() error = %v", err)
+	}
+	if c.TID != thread.ID {
+		t.Errorf("Database.NewComment() TID = %d, want %d", c.TID, thread.ID)
+	}
+	t.Run("pending", func(t *testing.T) {
+		if err := db.ActivateComment(context.Background(), c.ID); err != nil {
+			t.Errorf("Database.ActivateComment() error = %v", err)
+		}
+		got, err := db.GetComment(context.Background(), c.ID)
+		if err != nil {
+			t.Fatalf("Database.GetComment() error = %v", err)
+		}
+		if got.Mode != isso.ModeAccepted {
+			t.Errorf("Database.ActivateComment() mode = %d, want %d", got.Mode, isso.ModeAccepted)
+		}
+	})
+	t.Run("already activated", func(t *testing.T) {
+		err := db.ActivateComment(context.Background(), c.ID)
+		if !errors.Is(err, isso.ErrNotExpectAmount) {
+			t.Errorf("Database.ActivateComment() error = %v, wantErr %v", err, isso.ErrNotExpectAmount)
+		}
+	})
+}
+
+func TestDatabase_FetchComments(t *testing.T) {
+	thread, err := db.NewThread(context.Background(), "/fetch-all", "fetch all")
+	if err != nil {
+		t.Fatalf("Database.NewThread() error = %v", err)
+	}
+	before, err := db.CountCommentsByMode(context.Background())
+	if err != nil {
+		t.Fatalf("Database.CountCommentsByMode() error = %v", err)
+	}
+	for _, mode := range []int{isso.ModeAccepted, isso.ModeModeration, isso.ModeModeration} {
+		_, err := db.NewComment(context.Background(),
+			isso.Comment{Text: "fetch all", Author: "a", Mode: mode}, thread.ID, "127.0.0.1")
+		if err != nil {
+			t.Fatalf("Database.NewComment() error = %v", err)
+		}
+	}
+
+	counts, err := db.CountCommentsByMode(context.Background())
+	if err != nil {
+		t.Fatalf("Database.CountCommentsByMode() error = %v", err)
+	}
+	if got := counts[isso.ModeModeration] - before[isso.ModeModeration]; got != 2 {
+		t.Errorf("Database.CountCommentsByMode() pending = %d, want %d", got, 2)
+	}
+
+	comments, err := db.FetchComments(context.Background(), isso.ModeModeration, "id", false, 1, 0)
+	if err != nil {
+		t.Fatalf("Database.FetchComments() error = %v", err)
+	}
+	if len(comments) != 1 || comments[0].Mode != isso.ModeModeration || comments[0].TID != thread.ID {
+		t.Errorf("Database.FetchComments() = %v, want one pending comment of thread %d", comments, thread.ID)
+	}
+}
+
+func TestDatabase_UnsubscribeComment(t *testing.T) {
+	thread, err := db.NewThread(context.Background(), "/unsubscribe", "unsubscribe")
+	if err != nil {
+		t.Fatalf("Database.NewThread() error = %v", err)
+	}
+	email := "a@example.com"
+	c, err := db.NewComment(context.Background(),
+		isso.Comment{Text: "subscribed", Author: "a", Email: &email, Mode: isso.ModeAccepted, Notification: 1},
+		thread.ID, "127.0.0.1")
+	if err != nil {
+		t.Fatalf("Database.NewComment() error = %v", err)
+	}
+	if err := db.UnsubscribeComment(context.Background(), email, c.ID); err != nil {
+		t.Errorf("Database.UnsubscribeComment() error = %v", err)
+	}
+	got, err := db.GetComment(context.Background(), c.ID)
+	if err != nil {
+		t.Fatalf("Database.GetComment() error = %v", err)
+	}
+	if got.Notification != 0 {
+		t.Errorf("Database.UnsubscribeComment() notification = %d, want 0", got.Notification)
+	}
+}
+
+func TestDatabase_CountComment(t *testing.T) {
+	ctx := context.Background()
+	thread, err := db.NewThread(ctx, "/count", "count")
+	if err != nil {
+		t.Fatalf("Database.NewThread() error = %v", err)
+	}
+	for _, mode := range []int{isso.ModeAccepted, isso.ModeAccepted, isso.ModeModeration} {
+		if _, err := db.NewComment(ctx, isso.Comment{Text: "count", Author: "a", Mode: mode}, thread.ID, "127.0.0.1"); err != nil {
+			t.Fatalf("Database.NewComment() error = %v", err)
+		}
+	}
+
+	uris := []string{"/count-missing", "/count", "/count"}
+	for i := 0; i < 600; i++ {
+		uris = append(uris, fmt.Sprintf("/count-%d", i))
+	}
+	got, err := db.CountComment(ctx, uris)
+	if err != nil {
+		t.Fatalf("Database.CountComment() error = %v", err)
+	}
+	if len(got) != 602 || got["/count"] != 2 || got["/count-missing"] != 0 {
+		t.Errorf("Database.CountComment() = %d uris, /count = %d, /count-missing = %d", len(got), got["/count"], got["/count-missing"])
+	}
+}
@@ -1,7 +1,9 @@
 package database
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"log"
 	"os"
 	"testing"
@@ -13,11 +15,20 @@ import (
 
 var db *Database
 
+// TestMain use in-memory SQLite3 database, set GO_ISSO_TEST_DSN to
+// run tests against other database, e.g. postgres://isso@localhost/isso_test.
+// Tables in the test database will be dropped.
 func TestMain(m *testing.M) {
+	dsn := os.Getenv("GO_ISSO_TEST_DSN")
+	if driverOf(dsn) == "postgres" {
+		if err := dropPostgresTables(dsn); err != nil {
+			log.Fatalf("clean test database failed: %v", err)
+		}
+	}
 	var err error
-	db, err = New("", 1*time.Second)
+	db, err = New(dsn, 1*time.Second)
 	if err != nil {
-		log.Fatal("init database failed.")
+		log.Fatalf("init database failed: %v", err)
 	}
 	code := m.Run()
 
@@ -25,6 +36,33 @@ func TestMain(m *testing.M) {
 	os.Exit(code)
 }
 
+func dropPostgresTables(dsn string) error {
+	pg, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return err
+	}
+	defer pg.Close()
+	_, err = pg.Exec(`DROP TABLE IF EXISTS comments, threads, preferences CASCADE`)
+	return err
+}
+
+func Test_driverOf(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"", "sqlite3"},
+		{"/var/lib/isso/comments.db", "sqlite3"},
+		{"postgres://isso@localhost/isso?sslmode=disable", "postgres"},
+		{"postgresql://isso@localhost/isso", "postgres"},
+	}
+	for _, tt := range tests {
+		if got := driverOf(tt.path); got != tt.want {
+			t.Errorf("driverOf(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
 func Test_databaseError_Format(t *testing.T) {
 	t.Run("format", func(t *testing.T) {
 		err := wraperror(sql.ErrNoRows)
@@ -36,3 +74,30 @@ func Test_databaseError_Format(t *testing.T) {
 		t.Logf("%+s", err)
 	})
 }
+
+func TestDatabase_Transaction(t *testing.T) {
+	ctx := context.Background()
+	err := db.Transaction(ctx, func(tx *Database) error {
+		if _, err := tx.NewThread(ctx, "/transaction-rollback", "rollback"); err != nil {
+			return err
+		}
+		return errors.New("rollback")
+	})
+	if err == nil || err.Error() != "rollback" {
+		t.Fatalf("Database.Transaction() error = %v, want rollback", err)
+	}
+	if _, err := db.GetThreadByURI(ctx, "/transaction-rollback"); !errors.Is(err, isso.ErrStorageNotFound) {
+		t.Errorf("Database.Transaction() rolled back thread got error %v, want %v", err, isso.ErrStorageNotFound)
+	}
+
+	err = db.Transaction(ctx, func(tx *Database) error {
+		_, err := tx.NewThread(ctx, "/transaction-commit", "commit")
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Database.Transaction() error = %v", err)
+	}
+	if _, err := db.GetThreadByURI(ctx, "/transaction-commit"); err != nil {
+		t.Errorf("Database.Transaction() committed thread got error %v", err)
+	}
+}
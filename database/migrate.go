@@ -0,0 +1,121 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+
+	"wrong.wang/x/go-isso/isso"
+	"wrong.wang/x/go-isso/logger"
+)
+
+const schemaVersionKey = "schema_version"
+
+// Migration is an up-step of database schema.
+type Migration struct {
+	Version int
+	Name    string
+	Applied bool
+}
+
+// SchemaVersion return the version of database schema.
+// Database created before versioned migrations is detected by its columns.
+func (d *Database) SchemaVersion(ctx context.Context) (int, error) {
+	value, err := d.GetPreference(ctx, schemaVersionKey)
+	if err == nil {
+		version, err := strconv.Atoi(value)
+		if err != nil {
+			return 0, wraperror(err)
+		}
+		return version, nil
+	}
+	if !errors.Is(err, isso.ErrStorageNotFound) {
+		return 0, err
+	}
+
+	ctx, cancel := d.withTimeout(ctx)
+	defer cancel()
+	var version int
+	if err := d.conn.QueryRowContext(ctx, d.statement["schema_legacy_version"]).Scan(&version); err != nil {
+		return 0, wraperror(err)
+	}
+	return version, nil
+}
+
+// Migrations return all migrations and whether they are applied.
+func (d *Database) Migrations(ctx context.Context) ([]Migration, error) {
+	version, err := d.SchemaVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+	steps := make([]Migration, len(migrations))
+	for i, name := range migrations {
+		steps[i] = Migration{Version: i + 1, Name: name, Applied: i+1 <= version}
+	}
+	return steps, nil
+}
+
+// Migrate apply pending migrations in order, each in its own transaction.
+// It return the migrations applied, or would be applied when dryRun.
+func (d *Database) Migrate(ctx context.Context, dryRun bool) ([]Migration, error) {
+	steps, err := d.Migrations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pending := []Migration{}
+	for _, m := range steps {
+		if !m.Applied {
+			pending = append(pending, m)
+		}
+	}
+	if dryRun {
+		return pending, nil
+	}
+
+	for _, m := range pending {
+		logger.Info("apply migration %d %s", m.Version, m.Name)
+		if err := d.migrate(ctx, m); err != nil {
+			return nil, err
+		}
+	}
+	// record version of new or legacy database.
+	if _, err := d.GetPreference(ctx, schemaVersionKey); errors.Is(err, isso.ErrStorageNotFound) {
+		return pending, d.SetPreference(ctx, schemaVersionKey, strconv.Itoa(len(migrations)))
+	}
+	return pending, nil
+}
+
+// migrate apply m in a transaction without the query timeout,
+// as a migration may rewrite a whole table.
+func (d *Database) migrate(ctx context.Context, m Migration) error {
+	tx, err := d.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return wraperror(err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, d.statement["migrate_"+m.Name]); err != nil {
+		return wraperror(err)
+	}
+	if err := setVersion(ctx, tx, d.statement, m.Version); err != nil {
+		return wraperror(err)
+	}
+	if err := tx.Commit(); err != nil {
+		return wraperror(err)
+	}
+	return nil
+}
+
+func setVersion(ctx context.Context, tx *sql.Tx, statement map[string]string, version int) error {
+	value := strconv.Itoa(version)
+	result, err := tx.ExecContext(ctx, statement["preference_update"], value, schemaVersionKey)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil || n == 1 {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, statement["preference_set"], schemaVersionKey, value)
+	return err
+}
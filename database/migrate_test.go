@@ -0,0 +1,80 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDatabase_Migrate(t *testing.T) {
+	t.Run("new database", func(t *testing.T) {
+		version, err := db.SchemaVersion(context.Background())
+		if err != nil || version != len(migrations) {
+			t.Errorf("Database.SchemaVersion() = %v, %v, want %v", version, err, len(migrations))
+		}
+		pending, err := db.Migrate(context.Background(), false)
+		if err != nil || len(pending) != 0 {
+			t.Errorf("Database.Migrate() = %v, %v, want no migration", pending, err)
+		}
+	})
+
+	t.Run("legacy database", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "go-isso")
+		if err != nil {
+			t.Fatalf("create temp dir failed: %v", err)
+		}
+		defer os.RemoveAll(dir)
+		path := filepath.Join(dir, "comments.db")
+
+		legacy, err := sql.Open("sqlite3", path)
+		if err != nil {
+			t.Fatalf("open legacy database failed: %v", err)
+		}
+		_, err = legacy.Exec(`CREATE TABLE comments (
+			tid REFERENCES threads(id), id INTEGER PRIMARY KEY, parent INTEGER,
+			created FLOAT NOT NULL, modified FLOAT, mode INTEGER, remote_addr VARCHAR,
+			text VARCHAR, author VARCHAR, email VARCHAR, website VARCHAR,
+			likes INTEGER DEFAULT 0, dislikes INTEGER DEFAULT 0, voters BLOB NOT NULL);`)
+		legacy.Close()
+		if err != nil {
+			t.Fatalf("create legacy table failed: %v", err)
+		}
+
+		d, err := Open(path, time.Second)
+		if err != nil {
+			t.Fatalf("Open() error = %v", err)
+		}
+		defer d.Close()
+
+		pending, err := d.Migrate(context.Background(), true)
+		if err != nil || len(pending) != len(migrations) || pending[0].Name != "add_notification" {
+			t.Fatalf("Database.Migrate(dryRun) = %v, %v", pending, err)
+		}
+		if version, _ := d.SchemaVersion(context.Background()); version != 0 {
+			t.Errorf("dry run changed schema version to %d", version)
+		}
+
+		if _, err := d.Migrate(context.Background(), false); err != nil {
+			t.Fatalf("Database.Migrate() error = %v", err)
+		}
+		if _, err := d.Exec(`SELECT notification FROM comments`); err != nil {
+			t.Errorf("column notification not added: %v", err)
+		}
+		if value, err := d.GetPreference(context.Background(), schemaVersionKey); err != nil || value != "1" {
+			t.Errorf("schema_version = %v, %v, want 1", value, err)
+		}
+		steps, err := d.Migrations(context.Background())
+		if err != nil {
+			t.Fatalf("Database.Migrations() error = %v", err)
+		}
+		for _, m := range steps {
+			if !m.Applied {
+				t.Errorf("migration %d %s not applied", m.Version, m.Name)
+			}
+		}
+	})
+}
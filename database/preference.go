@@ -1,15 +1,19 @@
 package database
 
 import (
+	"context"
+
 	"wrong.wang/x/go-isso/isso"
 	"wrong.wang/x/go-isso/logger"
 )
 
 // GetPreference get preference use key
-func (d *Database) GetPreference(key string) (string, error) {
-	logger.Debug("key: %s", key)
+func (d *Database) GetPreference(ctx context.Context, key string) (string, error) {
+	logger.FromContext(ctx).Debug("key: %s", key)
+	ctx, cancel := d.withTimeout(ctx)
+	defer cancel()
 	var value string
-	err := d.DB.QueryRow(d.statement["preference_get"], key).Scan(&value)
+	err := d.conn.QueryRowContext(ctx, d.statement["preference_get"], key).Scan(&value)
 	if err != nil {
 		return "", wraperror(err)
 	}
@@ -17,9 +21,11 @@ func (d *Database) GetPreference(key string) (string, error) {
 }
 
 // SetPreference set preference with key value pairs.
-func (d *Database) SetPreference(key string, value string) error {
-	logger.Debug("key: %s, value %s", key, value)
-	result, err := d.DB.Exec(d.statement["preference_set"], key, value)
+func (d *Database) SetPreference(ctx context.Context, key string, value string) error {
+	logger.FromContext(ctx).Debug("key: %s", key)
+	ctx, cancel := d.withTimeout(ctx)
+	defer cancel()
+	result, err := d.conn.ExecContext(ctx, d.statement["preference_set"], key, value)
 	if err != nil {
 		return wraperror(err)
 	}
@@ -32,3 +38,46 @@ func (d *Database) SetPreference(key string, value string) error {
 	}
 	return nil
 }
+
+// FetchPreferences fetch all preferences.
+func (d *Database) FetchPreferences(ctx context.Context) (map[string]string, error) {
+	ctx, cancel := d.withTimeout(ctx)
+	defer cancel()
+	rows, err := d.conn.QueryContext(ctx, d.statement["preference_fetch_all"])
+	if err != nil {
+		return nil, wraperror(err)
+	}
+	defer rows.Close()
+
+	preferences := map[string]string{}
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
+			return nil, wraperror(err)
+		}
+		preferences[key] = value
+	}
+	if err := rows.Err(); err != nil {
+		return nil, wraperror(err)
+	}
+	return preferences, nil
+}
+
+// UpdatePreference update value of an existing preference.
+func (d *Database) UpdatePreference(ctx context.Context, key string, value string) error {
+	logger.FromContext(ctx).Debug("key: %s", key)
+	ctx, cancel := d.withTimeout(ctx)
+	defer cancel()
+	result, err := d.conn.ExecContext(ctx, d.statement["preference_update"], value, key)
+	if err != nil {
+		return wraperror(err)
+	}
+	row, err := result.RowsAffected()
+	if err != nil {
+		return wraperror(err)
+	}
+	if row == 0 {
+		return wraperror(isso.ErrStorageNotFound)
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package database
 
 import (
+	"context"
 	"errors"
 	"testing"
 
@@ -9,7 +10,7 @@ import (
 
 func TestDatabase_GetPreference(t *testing.T) {
 	t.Run("not exist key", func(t *testing.T) {
-		value, err := db.GetPreference("not-exist")
+		value, err := db.GetPreference(context.Background(), "not-exist")
 		if !errors.Is(err, isso.ErrStorageNotFound) {
 			t.Errorf("Database.GetPreference() want isso.ErrStorageNotFound's wrapper, but got %v", err)
 		}
@@ -18,11 +19,11 @@ func TestDatabase_GetPreference(t *testing.T) {
 		}
 	})
 	t.Run("exist key", func(t *testing.T) {
-		err := db.SetPreference("key", "value")
+		err := db.SetPreference(context.Background(), "key", "value")
 		if err != nil {
 			t.Errorf("Database.SetPreference() error = %v, wantErr %v", err, false)
 		}
-		value, err := db.GetPreference("key")
+		value, err := db.GetPreference(context.Background(), "key")
 		if err != nil {
 			t.Errorf("Database.GetPreference() error = %v, wantErr %v", err, false)
 		}
@@ -34,15 +35,15 @@ func TestDatabase_GetPreference(t *testing.T) {
 
 func TestDatabase_SetPreference(t *testing.T) {
 	t.Run("same key", func(t *testing.T) {
-		err := db.SetPreference("key1", "value")
+		err := db.SetPreference(context.Background(), "key1", "value")
 		if err != nil {
 			t.Errorf("Database.SetPreference() error = %v, wantErr %v", err, false)
 		}
-		err = db.SetPreference("key1", "value1")
+		err = db.SetPreference(context.Background(), "key1", "value1")
 		if err == nil {
 			t.Errorf("Database.SetPreference() error = %v, wantErr %v", err, true)
 		}
-		value, err := db.GetPreference("key1")
+		value, err := db.GetPreference(context.Background(), "key1")
 		if err != nil {
 			t.Errorf("Database.GetPreference() error = %v, wantErr %v", err, false)
 		}
@@ -51,3 +52,29 @@ func TestDatabase_SetPreference(t *testing.T) {
 		}
 	})
 }
+
+func TestDatabase_UpdatePreference(t *testing.T) {
+	t.Run("not exist key", func(t *testing.T) {
+		err := db.UpdatePreference(context.Background(), "update-not-exist", "value")
+		if !errors.Is(err, isso.ErrStorageNotFound) {
+			t.Errorf("Database.UpdatePreference() want isso.ErrStorageNotFound's wrapper, but got %v", err)
+		}
+	})
+	t.Run("exist key", func(t *testing.T) {
+		err := db.SetPreference(context.Background(), "update-key", "value")
+		if err != nil {
+			t.Errorf("Database.SetPreference() error = %v, wantErr %v", err, false)
+		}
+		err = db.UpdatePreference(context.Background(), "update-key", "value1")
+		if err != nil {
+			t.Errorf("Database.UpdatePreference() error = %v, wantErr %v", err, false)
+		}
+		value, err := db.GetPreference(context.Background(), "update-key")
+		if err != nil {
+			t.Errorf("Database.GetPreference() error = %v, wantErr %v", err, false)
+		}
+		if value != "value1" {
+			t.Errorf("expect `value1`, but got %s", value)
+		}
+	})
+}
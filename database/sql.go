@@ -1,5 +1,12 @@
 package database
 
+// migrations is the ordered up-steps of schema, step N upgrade schema from version N-1 to N,
+// its statement is presetSQL[driver]["migrate_"+name].
+// `create` is the schema of version 1 and must not change, new columns are added by migrations.
+var migrations = []string{
+	"add_notification",
+}
+
 var (
 	presetSQLITE3 map[string]string = map[string]string{
 		"create": `
@@ -36,18 +43,31 @@ var (
     	END;
 		`,
 		"migrate_add_notification": `ALTER TABLE comments ADD COLUMN notification INTEGER DEFAULT 0;`,
+		"schema_legacy_version":    `SELECT COUNT(*) FROM pragma_table_info('comments') WHERE name='notification';`,
 
-		"preference_get": `SELECT value FROM preferences WHERE key=$1;`,
-		"preference_set": `INSERT INTO preferences (key, value) VALUES ($1, $2);`,
+		"preference_get":       `SELECT value FROM preferences WHERE key=$1;`,
+		"preference_set":       `INSERT INTO preferences (key, value) VALUES ($1, $2);`,
+		"preference_update":    `UPDATE preferences SET value=$1 WHERE key=$2;`,
+		"preference_fetch_all": `SELECT key, value FROM preferences ORDER BY key;`,
 
 		"thread_get_by_uri": `SELECT * FROM threads WHERE uri=$1;`,
 		"thread_get_by_id":  `SELECT * FROM threads WHERE id=$1;`,
 		"thread_new":        `INSERT INTO threads (uri, title) VALUES ($1, $2);`,
+		"thread_fetch_all":  `SELECT * FROM threads ORDER BY id;`,
+		"thread_restore":    `INSERT INTO threads (id, uri, title) VALUES ($1, $2, $3);`,
 
 		"comment_new": `INSERT INTO comments (
         	tid, parent, created, modified, mode, remote_addr,
 			text, author, email, website, voters, notification
 		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12);`,
+		"comment_import": `INSERT INTO comments (
+			tid, parent, created, modified, mode, remote_addr, text, author,
+			email, website, likes, dislikes, voters, notification
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14);`,
+		"comment_restore": `INSERT INTO comments (
+			tid, id, parent, created, modified, mode, remote_addr, text, author,
+			email, website, likes, dislikes, voters, notification
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15);`,
 		"comment_get_by_id": `SELECT * FROM comments WHERE id=$1`,
 		"comment_is_previously_approved_author": `SELECT CASE WHEN EXISTS(
 			SELECT * FROM comments WHERE email=$1 AND mode=1 AND created > strftime("%s", DATETIME("now", "-6 month"))
@@ -57,8 +77,13 @@ var (
 			   ($2 | comments.mode = $3) AND comments.created > $4 GROUP BY comments.parent`,
 		"comment_fetch_by_uri": `SELECT comments.* FROM comments INNER JOIN threads ON
 			threads.uri=? AND comments.tid=threads.id AND (? | comments.mode) = ?`,
-		"comment_count": `SELECT threads.uri, COUNT(comments.id) FROM comments LEFT OUTER JOIN 
-		threads ON threads.id = tid AND comments.mode = 1 GROUP BY threads.uri`,
+		"comment_fetch_by_uri_and_parent": `SELECT comments.* FROM comments INNER JOIN threads ON
+			threads.uri=? AND comments.tid=threads.id AND (? | comments.mode) = ? AND comments.parent=?`,
+		"comment_fetch_all":     `SELECT * FROM comments WHERE (? | mode) = ? ORDER BY %s LIMIT ? OFFSET ?`,
+		"comment_count_by_mode": `SELECT mode, COUNT(*) FROM comments GROUP BY mode`,
+		"comment_count": `SELECT threads.uri, COUNT(comments.id) FROM threads INNER JOIN
+		comments ON comments.tid = threads.id AND comments.mode = 1
+		WHERE threads.uri IN (%s) GROUP BY threads.uri`,
 		"comment_activate":     `UPDATE comments SET mode=1 WHERE id=$1 AND mode=2;`,
 		"comment_unsubscribe":  `UPDATE comments SET notification=0 WHERE email=$1 AND (id=$2 OR parent=$2);`,
 		"comment_edit":         `UPDATE comments SET text=$1,author=$2,website=$3,modified=$4,email=$5 WHERE id=$6`,
@@ -77,6 +102,113 @@ var (
 	}
 )
 
+var presetPOSTGRES map[string]string = map[string]string{
+	"create": `
+		CREATE TABLE IF NOT EXISTS threads (
+			id BIGSERIAL PRIMARY KEY,
+			uri VARCHAR(256) UNIQUE,
+			title VARCHAR(256)
+		);
+		CREATE TABLE IF NOT EXISTS comments (
+			tid BIGINT REFERENCES threads(id),
+			id BIGSERIAL PRIMARY KEY,
+			parent BIGINT,
+			created DOUBLE PRECISION NOT NULL,
+			modified DOUBLE PRECISION,
+			mode INTEGER,
+			remote_addr VARCHAR,
+			text VARCHAR,
+			author VARCHAR,
+			email VARCHAR,
+			website VARCHAR,
+			likes INTEGER DEFAULT 0,
+			dislikes INTEGER DEFAULT 0,
+			voters BYTEA NOT NULL,
+			notification INTEGER DEFAULT 0
+		);
+		CREATE TABLE IF NOT EXISTS preferences (
+			key VARCHAR PRIMARY KEY,
+			value TEXT
+		);
+		CREATE OR REPLACE FUNCTION remove_stale_threads() RETURNS TRIGGER AS $$
+		BEGIN
+			DELETE FROM threads WHERE NOT EXISTS (SELECT 1 FROM comments WHERE comments.tid = threads.id);
+			RETURN NULL;
+		END;
+		$$ LANGUAGE plpgsql;
+		DROP TRIGGER IF EXISTS remove_stale_threads ON comments;
+		CREATE TRIGGER remove_stale_threads
+		AFTER DELETE ON comments
+		FOR EACH STATEMENT EXECUTE PROCEDURE remove_stale_threads();
+		`,
+	"migrate_add_notification": `ALTER TABLE comments ADD COLUMN IF NOT EXISTS notification INTEGER DEFAULT 0;`,
+	"schema_legacy_version": `SELECT COUNT(*) FROM information_schema.columns
+		WHERE table_schema = current_schema() AND table_name = 'comments' AND column_name = 'notification';`,
+
+	"preference_get":       `SELECT value FROM preferences WHERE key=$1;`,
+	"preference_set":       `INSERT INTO preferences (key, value) VALUES ($1, $2);`,
+	"preference_update":    `UPDATE preferences SET value=$1 WHERE key=$2;`,
+	"preference_fetch_all": `SELECT key, value FROM preferences ORDER BY key;`,
+
+	"thread_get_by_uri": `SELECT * FROM threads WHERE uri=$1;`,
+	"thread_get_by_id":  `SELECT * FROM threads WHERE id=$1;`,
+	"thread_new":        `INSERT INTO threads (uri, title) VALUES ($1, $2) RETURNING id;`,
+	"thread_fetch_all":  `SELECT * FROM threads ORDER BY id;`,
+	// explicit id does not advance the sequence.
+	"thread_restore": `WITH restored AS (
+			INSERT INTO threads (id, uri, title) VALUES ($1, $2, $3) RETURNING id
+		) SELECT setval(pg_get_serial_sequence('threads', 'id'),
+			GREATEST((SELECT id FROM restored), (SELECT COALESCE(MAX(id), 1) FROM threads)));`,
+
+	"comment_new": `INSERT INTO comments (
+			tid, parent, created, modified, mode, remote_addr,
+			text, author, email, website, voters, notification
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) RETURNING id;`,
+	"comment_import": `INSERT INTO comments (
+			tid, parent, created, modified, mode, remote_addr, text, author,
+			email, website, likes, dislikes, voters, notification
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14) RETURNING id;`,
+	"comment_restore": `WITH restored AS (
+			INSERT INTO comments (
+				tid, id, parent, created, modified, mode, remote_addr, text, author,
+				email, website, likes, dislikes, voters, notification
+			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15) RETURNING id
+		) SELECT setval(pg_get_serial_sequence('comments', 'id'),
+			GREATEST((SELECT id FROM restored), (SELECT COALESCE(MAX(id), 1) FROM comments)));`,
+	"comment_get_by_id": `SELECT * FROM comments WHERE id=$1`,
+	"comment_is_previously_approved_author": `SELECT CASE WHEN EXISTS(
+			SELECT * FROM comments WHERE email=$1 AND mode=1 AND created > EXTRACT(EPOCH FROM NOW() - INTERVAL '6 months')
+		) THEN 1 ELSE 0 END;`,
+	"comment_count_reply": `SELECT comments.parent,count(*)
+			FROM comments INNER JOIN threads ON threads.uri=$1 AND comments.tid=threads.id AND
+			   ($2::INTEGER | comments.mode) = $3 AND comments.created > $4 GROUP BY comments.parent`,
+	"comment_fetch_by_uri": `SELECT comments.* FROM comments INNER JOIN threads ON
+			threads.uri=$1 AND comments.tid=threads.id AND ($2::INTEGER | comments.mode) = $3`,
+	"comment_fetch_by_uri_and_parent": `SELECT comments.* FROM comments INNER JOIN threads ON
+			threads.uri=$1 AND comments.tid=threads.id AND ($2::INTEGER | comments.mode) = $3 AND comments.parent=$4`,
+	"comment_fetch_all":     `SELECT * FROM comments WHERE ($1::INTEGER | mode) = $2 ORDER BY %s LIMIT $3 OFFSET $4`,
+	"comment_count_by_mode": `SELECT mode, COUNT(*) FROM comments GROUP BY mode`,
+	"comment_count": `SELECT threads.uri, COUNT(comments.id) FROM threads INNER JOIN
+		comments ON comments.tid = threads.id AND comments.mode = 1
+		WHERE threads.uri IN (%s) GROUP BY threads.uri`,
+	"comment_activate":     `UPDATE comments SET mode=1 WHERE id=$1 AND mode=2;`,
+	"comment_unsubscribe":  `UPDATE comments SET notification=0 WHERE email=$1 AND (id=$2 OR parent=$2);`,
+	"comment_edit":         `UPDATE comments SET text=$1,author=$2,website=$3,modified=$4,email=$5 WHERE id=$6`,
+	"comment_delete_check": `SELECT COUNT(*) FROM comments WHERE parent=$1`,
+	"comment_delete_hard":  `DELETE FROM comments WHERE id=$1`,
+	"comment_delete_soft":  `UPDATE comments SET mode=4, text='', author='', website=NULL WHERE id=$1`,
+	"comment_delete_stale": `DELETE FROM comments
+		WHERE mode=4 AND id NOT IN (SELECT parent FROM comments WHERE parent IS NOT NULL)`,
+	"comment_vote_set": `UPDATE comments SET likes=$1, dislikes=$2, voters=$3 WHERE id=$4`,
+
+	"comment_guard_ratelimit": `SELECT COUNT(id) FROM comments WHERE remote_addr = $1 AND $2 - created < 60;`,
+	"comment_guard_3_direct_comment": `SELECT COUNT(id) FROM comments
+			WHERE tid = (SELECT id FROM threads WHERE uri = $1) AND remote_addr = $2 AND parent IS NULL;`,
+	"comment_guard_reply_to_self": `SELECT COUNT(id) FROM comments
+			WHERE remote_addr = $1 AND id = $2 AND $3 - created < $4;`,
+}
+
 var presetSQL map[string]map[string]string = map[string]map[string]string{
-	"sqlite3": presetSQLITE3,
+	"sqlite3":  presetSQLITE3,
+	"postgres": presetPOSTGRES,
 }
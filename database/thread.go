@@ -9,11 +9,11 @@ import (
 
 // GetThreadByURI get thread by uri
 func (d *Database) GetThreadByURI(ctx context.Context, uri string) (isso.Thread, error) {
-	logger.Debug("uri %s", uri)
+	logger.FromContext(ctx).Debug("uri %s", uri)
 	var thread isso.Thread
 	ctx, cancel := d.withTimeout(ctx)
 	defer cancel()
-	err := d.DB.QueryRowContext(ctx, d.statement["thread_get_by_uri"], uri).Scan(&thread.ID, &thread.URI, &thread.Title)
+	err := d.conn.QueryRowContext(ctx, d.statement["thread_get_by_uri"], uri).Scan(&thread.ID, &thread.URI, &thread.Title)
 	if err != nil {
 		return thread, wraperror(err)
 	}
@@ -22,11 +22,11 @@ func (d *Database) GetThreadByURI(ctx context.Context, uri string) (isso.Thread,
 
 // GetThreadByID get thread by id
 func (d *Database) GetThreadByID(ctx context.Context, id int64) (isso.Thread, error) {
-	logger.Debug("id %d", id)
+	logger.FromContext(ctx).Debug("id %d", id)
 	var thread isso.Thread
 	ctx, cancel := d.withTimeout(ctx)
 	defer cancel()
-	err := d.DB.QueryRowContext(ctx, d.statement["thread_get_by_id"], id).Scan(&thread.ID, &thread.URI, &thread.Title)
+	err := d.conn.QueryRowContext(ctx, d.statement["thread_get_by_id"], id).Scan(&thread.ID, &thread.URI, &thread.Title)
 	if err != nil {
 		return thread, wraperror(err)
 	}
@@ -35,7 +35,7 @@ func (d *Database) GetThreadByID(ctx context.Context, id int64) (isso.Thread, er
 
 // NewThread new a thread
 func (d *Database) NewThread(ctx context.Context, uri string, title string) (isso.Thread, error) {
-	logger.Debug("create thread %s %s", uri, title)
+	logger.FromContext(ctx).Debug("create thread %s %s", uri, title)
 	ctx, cancel := d.withTimeout(ctx)
 	defer cancel()
 
@@ -53,3 +53,41 @@ func (d *Database) NewThread(ctx context.Context, uri string, title string) (iss
 	}
 	return isso.Thread{ID: lastinsertid, URI: uri, Title: title}, nil
 }
+
+// FetchThreads fetch all threads
+func (d *Database) FetchThreads(ctx context.Context) ([]isso.Thread, error) {
+	ctx, cancel := d.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := d.conn.QueryContext(ctx, d.statement["thread_fetch_all"])
+	if err != nil {
+		return nil, wraperror(err)
+	}
+	defer rows.Close()
+
+	threads := []isso.Thread{}
+	for rows.Next() {
+		var thread isso.Thread
+		if err := rows.Scan(&thread.ID, &thread.URI, &thread.Title); err != nil {
+			return nil, wraperror(err)
+		}
+		threads = append(threads, thread)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, wraperror(err)
+	}
+	return threads, nil
+}
+
+// RestoreThread save thread with its id
+func (d *Database) RestoreThread(ctx context.Context, t isso.Thread) error {
+	logger.FromContext(ctx).Debug("restore thread %d %s", t.ID, t.URI)
+	ctx, cancel := d.withTimeout(ctx)
+	defer cancel()
+
+	err := d.execstmt(ctx, nil, nil, d.statement["thread_restore"], t.ID, t.URI, t.Title)
+	if err != nil {
+		return wraperror(err)
+	}
+	return nil
+}
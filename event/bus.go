@@ -9,7 +9,8 @@ import (
 // Bus for handlers and callbacks.
 type Bus struct {
 	sync.Mutex
-	handlers map[string][]*handler
+	handlers  map[string][]*handler
+	observers []func(topic string)
 }
 
 type handler struct {
@@ -28,11 +29,21 @@ func (bus *Bus) Subscribe(topic string, fn interface{}) error {
 	return nil
 }
 
+// OnPublish call fn with the topic on every publish, even without subscriber.
+func (bus *Bus) OnPublish(fn func(topic string)) {
+	bus.Lock()
+	defer bus.Unlock()
+	bus.observers = append(bus.observers, fn)
+}
+
 // Publish executes callback defined for a topic.
 // Any additional argument will be transferred to the callback.
 func (bus *Bus) Publish(topic string, args ...interface{}) {
 	bus.Lock()
 	defer bus.Unlock()
+	for _, fn := range bus.observers {
+		fn(topic)
+	}
 	if handlers, ok := bus.handlers[topic]; ok && 0 < len(handlers) {
 		for _, handler := range handlers {
 			go bus.doPublishAsync(handler, topic, args...)
@@ -62,7 +73,6 @@ func (bus *Bus) setUpPublish(callback *handler, args ...interface{}) []reflect.V
 // New returns new Bus with empty handlers.
 func New() *Bus {
 	return &Bus{
-		sync.Mutex{},
-		make(map[string][]*handler),
+		handlers: make(map[string][]*handler),
 	}
 }
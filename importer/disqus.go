@@ -0,0 +1,104 @@
+package importer
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"time"
+
+	"wrong.wang/x/go-isso/isso"
+)
+
+type disqusRef struct {
+	ID string `xml:"http://disqus.com/disqus-internals id,attr"`
+}
+
+type disqusExport struct {
+	Threads []struct {
+		disqusRef
+		Link  string `xml:"link"`
+		Title string `xml:"title"`
+	} `xml:"thread"`
+	Posts []struct {
+		disqusRef
+		Message   string `xml:"message"`
+		CreatedAt string `xml:"createdAt"`
+		IsDeleted bool   `xml:"isDeleted"`
+		IsSpam    bool   `xml:"isSpam"`
+		Author    struct {
+			Email string `xml:"email"`
+			Name  string `xml:"name"`
+		} `xml:"author"`
+		IPAddress string     `xml:"ipAddress"`
+		Thread    disqusRef  `xml:"thread"`
+		Parent    *disqusRef `xml:"parent"`
+	} `xml:"post"`
+}
+
+// FromDisqus read threads and comments from a Disqus XML export.
+// Spam is dropped, deleted posts are kept as deleted comments.
+func FromDisqus(r io.Reader) ([]Thread, error) {
+	var export disqusExport
+	if err := xml.NewDecoder(r).Decode(&export); err != nil {
+		return nil, fmt.Errorf("parse disqus export failed: %w", err)
+	}
+
+	threads := []Thread{}
+	// Disqus may have several threads for the same page.
+	threadByURI := map[string]int{}
+	threadByID := map[string]int{}
+	for _, t := range export.Threads {
+		uri, ok := threadURI(t.Link)
+		if !ok {
+			continue
+		}
+		i, ok := threadByURI[uri]
+		if !ok {
+			i = len(threads)
+			threadByURI[uri] = i
+			threads = append(threads, Thread{URI: uri, Title: t.Title})
+		}
+		threadByID[t.ID] = i
+	}
+
+	for _, p := range export.Posts {
+		if p.IsSpam {
+			continue
+		}
+		i, ok := threadByID[p.Thread.ID]
+		if !ok {
+			continue
+		}
+		created, err := time.Parse(time.RFC3339, p.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("post %s has invalid createdAt %s: %w", p.ID, p.CreatedAt, err)
+		}
+
+		c := Comment{
+			Comment: isso.Comment{
+				Created:    float64(created.UnixNano()) / float64(1e9),
+				Mode:       isso.ModeAccepted,
+				Text:       htmlToMarkdown(p.Message),
+				Author:     p.Author.Name,
+				RemoteAddr: p.IPAddress,
+			},
+			ID: p.ID,
+		}
+		if p.Author.Email != "" {
+			email := p.Author.Email
+			c.Email = &email
+		}
+		if p.Parent != nil {
+			c.Parent = p.Parent.ID
+		}
+		if p.IsDeleted {
+			c.Mode = isso.ModeDeleted
+			c.Text = ""
+			c.Author = ""
+			c.Email = nil
+		}
+		c.Voters = voters(c.RemoteAddr)
+		threads[i].Comments = append(threads[i].Comments, c)
+	}
+	return threads, nil
+}
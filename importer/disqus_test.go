@@ -0,0 +1,114 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+
+	"wrong.wang/x/go-isso/isso"
+)
+
+const disqusXML = `<?xml version="1.0" encoding="utf-8"?>
+<disqus xmlns="http://disqus.com" xmlns:dsq="http://disqus.com/disqus-internals">
+	<category dsq:id="1"><forum>blog</forum><title>General</title></category>
+	<thread dsq:id="10">
+		<link>https://example.com/hello/</link>
+		<title>Hello</title>
+		<createdAt>2015-01-01T00:00:00Z</createdAt>
+	</thread>
+	<thread dsq:id="11">
+		<link>https://example.com/hello/</link>
+		<title>Hello again</title>
+	</thread>
+	<thread dsq:id="12">
+		<link>https://example.com/empty/</link>
+		<title>Empty</title>
+	</thread>
+	<post dsq:id="100">
+		<message><![CDATA[<p>first <b>post</b></p>]]></message>
+		<createdAt>2015-01-02T00:00:00Z</createdAt>
+		<isDeleted>false</isDeleted>
+		<isSpam>false</isSpam>
+		<author><email>a@example.com</email><name>Alice</name></author>
+		<ipAddress>1.2.3.4</ipAddress>
+		<thread dsq:id="10"/>
+	</post>
+	<post dsq:id="101">
+		<message><![CDATA[<p>deleted</p>]]></message>
+		<createdAt>2015-01-03T00:00:00Z</createdAt>
+		<isDeleted>true</isDeleted>
+		<isSpam>false</isSpam>
+		<author><name>Bob</name></author>
+		<ipAddress>1.2.3.5</ipAddress>
+		<thread dsq:id="11"/>
+		<parent dsq:id="100"/>
+	</post>
+	<post dsq:id="102">
+		<message><![CDATA[<p>buy now</p>]]></message>
+		<createdAt>2015-01-03T00:00:00Z</createdAt>
+		<isDeleted>false</isDeleted>
+		<isSpam>true</isSpam>
+		<author><name>Spam</name></author>
+		<ipAddress>1.2.3.6</ipAddress>
+		<thread dsq:id="12"/>
+	</post>
+</disqus>`
+
+func TestFromDisqus(t *testing.T) {
+	threads, err := FromDisqus(strings.NewReader(disqusXML))
+	if err != nil {
+		t.Fatalf("FromDisqus() error = %v", err)
+	}
+	if len(threads) != 2 {
+		t.Fatalf("FromDisqus() got %d threads, want 2", len(threads))
+	}
+	hello := threads[0]
+	if hello.URI != "/hello/" || hello.Title != "Hello" || len(hello.Comments) != 2 {
+		t.Fatalf("FromDisqus() thread = %+v", hello)
+	}
+	first := hello.Comments[0]
+	if first.Text != "first **post**" || first.Author != "Alice" || first.Email == nil ||
+		*first.Email != "a@example.com" || first.RemoteAddr != "1.2.3.4" || first.Created != 1420156800 {
+		t.Errorf("FromDisqus() comment = %+v", first)
+	}
+	deleted := hello.Comments[1]
+	if deleted.Mode != isso.ModeDeleted || deleted.Text != "" || deleted.Parent != "100" {
+		t.Errorf("FromDisqus() deleted comment = %+v", deleted)
+	}
+	if len(threads[1].Comments) != 0 {
+		t.Errorf("FromDisqus() spam should be dropped: %+v", threads[1].Comments)
+	}
+	if got := pruneDeleted(hello.Comments); len(got) != 1 {
+		t.Errorf("pruneDeleted() = %+v, want deleted leaf dropped", got)
+	}
+}
+
+const disqusQueryXML = `<?xml version="1.0" encoding="utf-8"?>
+<disqus xmlns="http://disqus.com" xmlns:dsq="http://disqus.com/disqus-internals">
+	<thread dsq:id="10"><link>https://example.com/post?id=1</link><title>One</title></thread>
+	<thread dsq:id="11"><link>https://example.com/post?id=2</link><title>Two</title></thread>
+	<post dsq:id="100">
+		<message><![CDATA[<p>one</p>]]></message>
+		<createdAt>2015-01-02T00:00:00Z</createdAt>
+		<thread dsq:id="10"/>
+	</post>
+	<post dsq:id="101">
+		<message><![CDATA[<p>two</p>]]></message>
+		<createdAt>2015-01-03T00:00:00Z</createdAt>
+		<thread dsq:id="11"/>
+	</post>
+</disqus>`
+
+func TestFromDisqus_query(t *testing.T) {
+	threads, err := FromDisqus(strings.NewReader(disqusQueryXML))
+	if err != nil {
+		t.Fatalf("FromDisqus() error = %v", err)
+	}
+	if len(threads) != 2 {
+		t.Fatalf("FromDisqus() got %d threads, want 2", len(threads))
+	}
+	for i, want := range []string{"/post?id=1", "/post?id=2"} {
+		if threads[i].URI != want || len(threads[i].Comments) != 1 {
+			t.Errorf("FromDisqus() thread %d = %+v, want uri %s with 1 comment", i, threads[i], want)
+		}
+	}
+}
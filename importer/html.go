@@ -0,0 +1,90 @@
+package importer
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+var blankLines = regexp.MustCompile(`\n{3,}`)
+
+// htmlToMarkdown convert the HTML comments exported by other systems to markdown,
+// go-isso save comments as markdown and do not render raw HTML.
+func htmlToMarkdown(s string) string {
+	nodes, err := html.ParseFragment(strings.NewReader(s), &html.Node{Type: html.ElementNode, DataAtom: atom.Body, Data: "body"})
+	if err != nil {
+		return s
+	}
+	var b strings.Builder
+	for _, n := range nodes {
+		writeMarkdown(&b, n)
+	}
+	return strings.TrimSpace(blankLines.ReplaceAllString(b.String(), "\n\n"))
+}
+
+func writeMarkdown(b *strings.Builder, n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		b.WriteString(n.Data)
+		return
+	case html.ElementNode:
+	default:
+		return
+	}
+
+	children := func() string {
+		var cb strings.Builder
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			writeMarkdown(&cb, c)
+		}
+		return cb.String()
+	}
+
+	switch n.DataAtom {
+	case atom.Br:
+		b.WriteString("  \n")
+	case atom.P, atom.Div:
+		fmt.Fprintf(b, "\n\n%s\n\n", strings.TrimSpace(children()))
+	case atom.B, atom.Strong:
+		fmt.Fprintf(b, "**%s**", children())
+	case atom.I, atom.Em:
+		fmt.Fprintf(b, "*%s*", children())
+	case atom.Del, atom.S, atom.Strike:
+		fmt.Fprintf(b, "~~%s~~", children())
+	case atom.Code:
+		fmt.Fprintf(b, "`%s`", children())
+	case atom.Pre:
+		fmt.Fprintf(b, "\n\n```\n%s\n```\n\n", strings.Trim(strings.Replace(children(), "`", "", -1), "\n"))
+	case atom.A:
+		text := children()
+		href := attr(n, "href")
+		if href == "" || href == text {
+			b.WriteString(text)
+		} else {
+			fmt.Fprintf(b, "[%s](%s)", text, href)
+		}
+	case atom.Img:
+		fmt.Fprintf(b, "![%s](%s)", attr(n, "alt"), attr(n, "src"))
+	case atom.Blockquote:
+		quoted := strings.TrimSpace(blankLines.ReplaceAllString(children(), "\n\n"))
+		fmt.Fprintf(b, "\n\n> %s\n\n", strings.Replace(quoted, "\n", "\n> ", -1))
+	case atom.Li:
+		fmt.Fprintf(b, "\n* %s", strings.TrimSpace(children()))
+	case atom.Ul, atom.Ol:
+		fmt.Fprintf(b, "\n%s\n\n", children())
+	default:
+		b.WriteString(children())
+	}
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
@@ -0,0 +1,27 @@
+package importer
+
+import "testing"
+
+func Test_htmlToMarkdown(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"plain", "hello", "hello"},
+		{"paragraphs", "<p>one</p><p>two<br>three</p>", "one\n\ntwo  \nthree"},
+		{"inline", "<b>b</b> <em>i</em> <code>c</code>", "**b** *i* `c`"},
+		{"link", `<a href="https://example.com">site</a>`, "[site](https://example.com)"},
+		{"bare link", `<a href="https://example.com">https://example.com</a>`, "https://example.com"},
+		{"quote", "<blockquote>a<br>b</blockquote>", "> a  \n> b"},
+		{"list", "<ul><li>a</li><li>b</li></ul>", "* a\n* b"},
+		{"entity", "a &amp; b", "a & b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := htmlToMarkdown(tt.in); got != tt.want {
+				t.Errorf("htmlToMarkdown() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,263 @@
+// Package importer read comments exported from other commenting systems,
+// and merge them into go-isso's storage.
+package importer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+
+	"wrong.wang/x/go-isso/isso"
+	"wrong.wang/x/go-isso/logger"
+	"wrong.wang/x/go-isso/tool/bloomfilter"
+)
+
+// Storage is the part of isso.Storage used by importer.
+type Storage interface {
+	GetThreadByURI(ctx context.Context, uri string) (isso.Thread, error)
+	NewThread(ctx context.Context, uri string, title string) (isso.Thread, error)
+	ImportComment(ctx context.Context, c isso.Comment) (isso.Comment, error)
+	FetchCommentsByURI(ctx context.Context, uri string, parent int64, mode int, orderBy string, asc bool) (map[int64][]isso.Comment, error)
+}
+
+// every mode stored in database, used to find imported comments.
+const allModes = isso.ModeAccepted | isso.ModeModeration | isso.ModeDeleted
+
+// Thread is a thread read from source, with its comments.
+type Thread struct {
+	URI      string
+	Title    string
+	Comments []Comment
+}
+
+// Comment is a comment read from source.
+// ID and Parent are the identifiers used by source, they are only used to
+// rebuild the relationship between comments, never saved.
+type Comment struct {
+	isso.Comment
+	ID     string
+	Parent string
+}
+
+// Report summarize an import.
+type Report struct {
+	NewThreads      int
+	ExistingThreads int
+	Comments        map[int]int
+	Orphans         int
+	// Duplicates is comments already in storage, e.g. imported before.
+	Duplicates int
+}
+
+// Print write a human readable report.
+func (r Report) Print(w io.Writer, dryRun bool) {
+	verb := "imported"
+	if dryRun {
+		verb = "would import"
+	}
+	var total int
+	for _, n := range r.Comments {
+		total += n
+	}
+	fmt.Fprintf(w, "%s %d threads (%d new, %d existing)\n", verb, r.NewThreads+r.ExistingThreads,
+		r.NewThreads, r.ExistingThreads)
+	fmt.Fprintf(w, "%s %d comments (%d public, %d pending, %d deleted)\n", verb, total,
+		r.Comments[isso.ModeAccepted], r.Comments[isso.ModeModeration], r.Comments[isso.ModeDeleted])
+	if r.Duplicates > 0 {
+		fmt.Fprintf(w, "%d comments already exist and were skipped\n", r.Duplicates)
+	}
+	if r.Orphans > 0 {
+		fmt.Fprintf(w, "%d comments lost their parent and became top-level comments\n", r.Orphans)
+	}
+}
+
+// Save merge threads into storage. Threads are matched by uri,
+// comment ids and parents are remapped to the ids in storage.
+// Comments already in the thread, with the same created time, remote address
+// and text, are skipped, so importing again only adds new comments.
+// Nothing is written when dryRun is true.
+func Save(ctx context.Context, storage Storage, threads []Thread, dryRun bool) (Report, error) {
+	report := Report{Comments: map[int]int{}}
+	for _, t := range threads {
+		t.Comments = pruneDeleted(t.Comments)
+		if len(t.Comments) == 0 {
+			// go-isso never keeps threads without comments.
+			continue
+		}
+		existing := map[string]isso.Comment{}
+		thread, err := storage.GetThreadByURI(ctx, t.URI)
+		switch {
+		case err == nil:
+			report.ExistingThreads++
+			if existing, err = existingComments(ctx, storage, t.URI); err != nil {
+				return report, err
+			}
+		case errors.Is(err, isso.ErrStorageNotFound):
+			report.NewThreads++
+			if !dryRun {
+				title := t.Title
+				if title == "" {
+					title = t.URI
+				}
+				if thread, err = storage.NewThread(ctx, t.URI, title); err != nil {
+					return report, fmt.Errorf("create thread %s failed: %w", t.URI, err)
+				}
+			}
+		default:
+			return report, fmt.Errorf("get thread %s failed: %w", t.URI, err)
+		}
+
+		comments, orphans := sortByParent(t.Comments)
+		report.Orphans += orphans
+
+		// top maps source id to the new id of its top-level ancestor,
+		// isso only has two levels of comments.
+		top := map[string]int64{}
+		for _, c := range comments {
+			if saved, ok := existing[commentKey(c.Comment)]; ok {
+				report.Duplicates++
+				top[c.ID] = saved.ID
+				if saved.Parent != nil {
+					top[c.ID] = *saved.Parent
+				}
+				continue
+			}
+			report.Comments[c.Mode]++
+			if dryRun {
+				continue
+			}
+			nc := c.Comment
+			nc.TID = thread.ID
+			nc.Parent = nil
+			if parent, ok := top[c.Parent]; ok && c.Parent != "" {
+				nc.Parent = &parent
+			}
+			saved, err := storage.ImportComment(ctx, nc)
+			if err != nil {
+				return report, fmt.Errorf("import comment %s of %s failed: %w", c.ID, t.URI, err)
+			}
+			if saved.Parent != nil {
+				top[c.ID] = *saved.Parent
+			} else {
+				top[c.ID] = saved.ID
+			}
+		}
+		logger.Debug("import thread %s with %d comments", t.URI, len(comments))
+	}
+	return report, nil
+}
+
+// existingComments return comments of thread uri by commentKey.
+func existingComments(ctx context.Context, storage Storage, uri string) (map[string]isso.Comment, error) {
+	commentsByParent, err := storage.FetchCommentsByURI(ctx, uri, -1, allModes, "id", true)
+	if err != nil {
+		return nil, fmt.Errorf("fetch comments of %s failed: %w", uri, err)
+	}
+	existing := map[string]isso.Comment{}
+	for _, comments := range commentsByParent {
+		for _, c := range comments {
+			existing[commentKey(c)] = c
+		}
+	}
+	return existing, nil
+}
+
+// threadURI return the path and query of a page link, pages like /?p=12
+// or /post?id=1 differ only in the query.
+func threadURI(link string) (string, bool) {
+	u, err := url.Parse(link)
+	if err != nil || (u.Path == "" && u.RawQuery == "") {
+		return "", false
+	}
+	uri := u.Path
+	if uri == "" {
+		uri = "/"
+	}
+	if u.RawQuery != "" {
+		uri += "?" + u.RawQuery
+	}
+	return uri, true
+}
+
+// commentKey identify a comment across imports.
+func commentKey(c isso.Comment) string {
+	return fmt.Sprintf("%v\x00%s\x00%s", c.Created, c.RemoteAddr, c.Text)
+}
+
+// pruneDeleted drop deleted comments which are not referenced by any reply.
+func pruneDeleted(comments []Comment) []Comment {
+	for {
+		referenced := map[string]bool{}
+		for _, c := range comments {
+			if c.Parent != "" {
+				referenced[c.Parent] = true
+			}
+		}
+		kept := comments[:0:0]
+		for _, c := range comments {
+			if c.Mode != isso.ModeDeleted || referenced[c.ID] {
+				kept = append(kept, c)
+			}
+		}
+		if len(kept) == len(comments) {
+			return kept
+		}
+		comments = kept
+	}
+}
+
+// sortByParent order comments so that parents always come before their replies.
+// Comments whose parent can not be found become top-level comments.
+func sortByParent(comments []Comment) ([]Comment, int) {
+	sort.SliceStable(comments, func(i, j int) bool {
+		return comments[i].Created < comments[j].Created
+	})
+	known := map[string]bool{}
+	for _, c := range comments {
+		known[c.ID] = true
+	}
+
+	var orphans int
+	sorted := make([]Comment, 0, len(comments))
+	placed := map[string]bool{}
+	for len(sorted) < len(comments) {
+		progress := false
+		for _, c := range comments {
+			if placed[c.ID] {
+				continue
+			}
+			if c.Parent != "" && !known[c.Parent] {
+				c.Parent = ""
+				orphans++
+			}
+			if c.Parent == "" || placed[c.Parent] {
+				sorted = append(sorted, c)
+				placed[c.ID] = true
+				progress = true
+			}
+		}
+		if !progress {
+			// cycle in source data, break it.
+			for _, c := range comments {
+				if !placed[c.ID] {
+					c.Parent = ""
+					orphans++
+					sorted = append(sorted, c)
+					placed[c.ID] = true
+					break
+				}
+			}
+		}
+	}
+	return sorted, orphans
+}
+
+// voters return the voters of a new comment from remoteAddr.
+func voters(remoteAddr string) [256]byte {
+	bf := bloomfilter.New()
+	bf.Add([]byte(remoteAddr))
+	return bf.Buffer()
+}
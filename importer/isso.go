@@ -0,0 +1,118 @@
+package importer
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"strconv"
+
+	// sqlite3 driver
+	_ "github.com/mattn/go-sqlite3"
+	"gopkg.in/guregu/null.v4"
+	"wrong.wang/x/go-isso/isso"
+)
+
+// FromISSO read threads and comments from a Python isso SQLite database.
+// The database is opened read-only.
+func FromISSO(path string) ([]Thread, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+	db, err := sql.Open("sqlite3", fmt.Sprintf("file:%s?mode=ro", path))
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	// very old isso database does not have `notification`.
+	notification := "0"
+	if ok, err := hasColumn(db, "comments", "notification"); err != nil {
+		return nil, err
+	} else if ok {
+		notification = "notification"
+	}
+
+	threads := []Thread{}
+	threadByID := map[int64]int{}
+	rows, err := db.Query(`SELECT id, uri, title FROM threads ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int64
+		var uri, title null.String
+		if err := rows.Scan(&id, &uri, &title); err != nil {
+			return nil, err
+		}
+		threadByID[id] = len(threads)
+		threads = append(threads, Thread{URI: uri.String, Title: title.String})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = db.Query(`SELECT tid, id, parent, created, modified, mode, remote_addr, text, author,
+		email, website, likes, dislikes, voters, ` + notification + ` FROM comments ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var tid, id int64
+		var parent null.Int
+		var modified null.Float
+		var remoteAddr, text, author, email, website null.String
+		var likes, dislikes null.Int
+		var voters []byte
+		var c isso.Comment
+		if err := rows.Scan(&tid, &id, &parent, &c.Created, &modified, &c.Mode, &remoteAddr, &text,
+			&author, &email, &website, &likes, &dislikes, &voters, &c.Notification); err != nil {
+			return nil, err
+		}
+		i, ok := threadByID[tid]
+		if !ok {
+			// comments without thread can never be shown.
+			continue
+		}
+		c.Modified = modified.Ptr()
+		c.RemoteAddr = remoteAddr.String
+		c.Text = text.String
+		c.Author = author.String
+		c.Email = email.Ptr()
+		c.Website = website.Ptr()
+		c.Likes = int(likes.Int64)
+		c.Dislikes = int(dislikes.Int64)
+		copy(c.Voters[:], voters)
+
+		ic := Comment{Comment: c, ID: strconv.FormatInt(id, 10)}
+		if parent.Valid {
+			ic.Parent = strconv.FormatInt(parent.Int64, 10)
+		}
+		threads[i].Comments = append(threads[i].Comments, ic)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return threads, nil
+}
+
+func hasColumn(db *sql.DB, table, column string) (bool, error) {
+	rows, err := db.Query(fmt.Sprintf(`PRAGMA table_info(%s)`, table))
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var cid, notnull, pk int
+		var name, ctype string
+		var dflt null.String
+		if err := rows.Scan(&cid, &name, &ctype, &notnull, &dflt, &pk); err != nil {
+			return false, err
+		}
+		if name == column {
+			return true, nil
+		}
+	}
+	return false, rows.Err()
+}
@@ -0,0 +1,123 @@
+package importer
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"wrong.wang/x/go-isso/database"
+	"wrong.wang/x/go-isso/isso"
+)
+
+func TestFromISSO(t *testing.T) {
+	ctx := context.Background()
+	dir, err := ioutil.TempDir("", "go-isso")
+	if err != nil {
+		t.Fatalf("create temp dir failed: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "comments.db")
+	source, err := database.New(path, time.Second)
+	if err != nil {
+		t.Fatalf("create source database failed: %v", err)
+	}
+	st, _ := source.NewThread(ctx, "/post", "Post")
+	email := "a@example.com"
+	c1, _ := source.NewComment(ctx, isso.Comment{Text: "top", Author: "a", Email: &email, Mode: isso.ModeAccepted}, st.ID, "1.2.3.4")
+	c2, _ := source.NewComment(ctx, isso.Comment{Text: "reply", Author: "b", Mode: isso.ModeModeration, Parent: &c1.ID}, st.ID, "1.2.3.5")
+	source.VoteComment(ctx, c1, true)
+	source.Close()
+
+	threads, err := FromISSO(path)
+	if err != nil {
+		t.Fatalf("FromISSO() error = %v", err)
+	}
+	if len(threads) != 1 || len(threads[0].Comments) != 2 {
+		t.Fatalf("FromISSO() = %v, want 1 thread with 2 comments", threads)
+	}
+
+	target, err := database.New("", time.Second)
+	if err != nil {
+		t.Fatalf("create target database failed: %v", err)
+	}
+	defer target.Close()
+	// occupy the ids used by source.
+	tt, _ := target.NewThread(ctx, "/post", "Post")
+	target.NewComment(ctx, isso.Comment{Text: "existing", Author: "c", Mode: isso.ModeAccepted}, tt.ID, "1.1.1.1")
+	target.NewComment(ctx, isso.Comment{Text: "existing", Author: "c", Mode: isso.ModeAccepted}, tt.ID, "1.1.1.1")
+
+	t.Run("dry run", func(t *testing.T) {
+		report, err := Save(ctx, target, threads, true)
+		if err != nil {
+			t.Fatalf("Save() error = %v", err)
+		}
+		if report.ExistingThreads != 1 || report.Comments[isso.ModeAccepted] != 1 || report.Comments[isso.ModeModeration] != 1 {
+			t.Errorf("Save() report = %+v", report)
+		}
+		counts, _ := target.CountCommentsByMode(ctx)
+		if counts[isso.ModeAccepted] != 2 || counts[isso.ModeModeration] != 0 {
+			t.Errorf("dry run wrote to storage: %v", counts)
+		}
+	})
+	t.Run("import", func(t *testing.T) {
+		if _, err := Save(ctx, target, threads, false); err != nil {
+			t.Fatalf("Save() error = %v", err)
+		}
+		comments, err := target.FetchComments(ctx, isso.ModeModeration, "id", true, 10, 0)
+		if err != nil || len(comments) != 1 {
+			t.Fatalf("FetchComments() = %v, %v", comments, err)
+		}
+		reply := comments[0]
+		if reply.Parent == nil || *reply.Parent == c1.ID || reply.TID != tt.ID {
+			t.Errorf("reply parent = %v, thread = %d, want remapped parent in thread %d", reply.Parent, reply.TID, tt.ID)
+		}
+		parent, err := target.GetComment(ctx, *reply.Parent)
+		if err != nil || parent.Text != "top" || parent.Likes != 1 || parent.Email == nil || *parent.Email != email {
+			t.Errorf("parent = %+v, %v", parent, err)
+		}
+		if reply.Created != c2.Created {
+			t.Errorf("created time changed: %v, want %v", reply.Created, c2.Created)
+		}
+	})
+	t.Run("import again", func(t *testing.T) {
+		report, err := Save(ctx, target, threads, false)
+		if err != nil {
+			t.Fatalf("Save() error = %v", err)
+		}
+		if report.Duplicates != 2 || report.Comments[isso.ModeAccepted] != 0 || report.Comments[isso.ModeModeration] != 0 {
+			t.Errorf("Save() report = %+v, want 2 duplicates", report)
+		}
+		counts, _ := target.CountCommentsByMode(ctx)
+		if counts[isso.ModeAccepted] != 3 || counts[isso.ModeModeration] != 1 {
+			t.Errorf("import again duplicated comments: %v", counts)
+		}
+	})
+}
+
+func Test_sortByParent(t *testing.T) {
+	comments := []Comment{
+		{Comment: isso.Comment{Created: 1}, ID: "c", Parent: "b"},
+		{Comment: isso.Comment{Created: 2}, ID: "b", Parent: "a"},
+		{Comment: isso.Comment{Created: 3}, ID: "a"},
+		{Comment: isso.Comment{Created: 4}, ID: "d", Parent: "missing"},
+	}
+	sorted, orphans := sortByParent(comments)
+	if orphans != 1 {
+		t.Errorf("sortByParent() orphans = %d, want 1", orphans)
+	}
+	index := map[string]int{}
+	for i, c := range sorted {
+		index[c.ID] = i
+	}
+	for _, c := range sorted {
+		if c.Parent != "" && index[c.Parent] > index[c.ID] {
+			t.Errorf("sortByParent() put %s before its parent %s", c.ID, c.Parent)
+		}
+	}
+	if d := sorted[index["d"]]; d.Parent != "" {
+		t.Errorf("sortByParent() orphan keep parent %s", d.Parent)
+	}
+}
@@ -0,0 +1,101 @@
+package importer
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"time"
+
+	"wrong.wang/x/go-isso/isso"
+)
+
+const wordpressTimeLayout = "2006-01-02 15:04:05"
+
+// WXR namespace changes with WordPress version, so only local names are used.
+type wordpressExport struct {
+	Items []struct {
+		Title    string `xml:"title"`
+		Link     string `xml:"link"`
+		Comments []struct {
+			ID          string `xml:"comment_id"`
+			Author      string `xml:"comment_author"`
+			AuthorEmail string `xml:"comment_author_email"`
+			AuthorURL   string `xml:"comment_author_url"`
+			AuthorIP    string `xml:"comment_author_IP"`
+			Date        string `xml:"comment_date"`
+			DateGMT     string `xml:"comment_date_gmt"`
+			Content     string `xml:"comment_content"`
+			Approved    string `xml:"comment_approved"`
+			Type        string `xml:"comment_type"`
+			Parent      string `xml:"comment_parent"`
+		} `xml:"comment"`
+	} `xml:"channel>item"`
+}
+
+// FromWordPress read threads and comments from a WordPress eXtended RSS export.
+// Approved comments are public, pending comments wait for moderation,
+// spam, trash, pingbacks and trackbacks are dropped.
+func FromWordPress(r io.Reader) ([]Thread, error) {
+	var export wordpressExport
+	if err := xml.NewDecoder(r).Decode(&export); err != nil {
+		return nil, fmt.Errorf("parse wordpress export failed: %w", err)
+	}
+
+	threads := []Thread{}
+	for _, item := range export.Items {
+		uri, ok := threadURI(item.Link)
+		if !ok {
+			continue
+		}
+		thread := Thread{URI: uri, Title: item.Title}
+		for _, wc := range item.Comments {
+			if wc.Type == "pingback" || wc.Type == "trackback" {
+				continue
+			}
+			var mode int
+			switch wc.Approved {
+			case "1":
+				mode = isso.ModeAccepted
+			case "0":
+				mode = isso.ModeModeration
+			default:
+				continue
+			}
+
+			created, err := time.Parse(wordpressTimeLayout, wc.DateGMT)
+			if err != nil || created.Year() < 1970 {
+				// old WordPress may leave `0000-00-00 00:00:00` in date_gmt.
+				created, err = time.ParseInLocation(wordpressTimeLayout, wc.Date, time.Local)
+				if err != nil {
+					return nil, fmt.Errorf("comment %s has invalid comment_date %s: %w", wc.ID, wc.Date, err)
+				}
+			}
+
+			c := Comment{
+				Comment: isso.Comment{
+					Created:    float64(created.UnixNano()) / float64(1e9),
+					Mode:       mode,
+					Text:       htmlToMarkdown(wc.Content),
+					Author:     wc.Author,
+					RemoteAddr: wc.AuthorIP,
+				},
+				ID: wc.ID,
+			}
+			if wc.AuthorEmail != "" {
+				email := wc.AuthorEmail
+				c.Email = &email
+			}
+			if wc.AuthorURL != "" {
+				website := wc.AuthorURL
+				c.Website = &website
+			}
+			if wc.Parent != "" && wc.Parent != "0" {
+				c.Parent = wc.Parent
+			}
+			c.Voters = voters(c.RemoteAddr)
+			thread.Comments = append(thread.Comments, c)
+		}
+		threads = append(threads, thread)
+	}
+	return threads, nil
+}
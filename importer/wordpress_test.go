@@ -0,0 +1,121 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+
+	"wrong.wang/x/go-isso/isso"
+)
+
+const wordpressXML = `<?xml version="1.0" encoding="UTF-8" ?>
+<rss version="2.0" xmlns:content="http://purl.org/rss/1.0/modules/content/" xmlns:wp="http://wordpress.org/export/1.2/">
+<channel>
+	<title>Blog</title>
+	<link>https://blog.example.com</link>
+	<item>
+		<title>Hello World</title>
+		<link>https://blog.example.com/2015/01/hello-world/</link>
+		<wp:post_id>5</wp:post_id>
+		<wp:comment>
+			<wp:comment_id>1</wp:comment_id>
+			<wp:comment_author><![CDATA[Alice]]></wp:comment_author>
+			<wp:comment_author_email><![CDATA[a@example.com]]></wp:comment_author_email>
+			<wp:comment_author_url>https://alice.example.com</wp:comment_author_url>
+			<wp:comment_author_IP><![CDATA[1.2.3.4]]></wp:comment_author_IP>
+			<wp:comment_date><![CDATA[2015-01-01 10:00:00]]></wp:comment_date>
+			<wp:comment_date_gmt><![CDATA[2015-01-01 09:00:00]]></wp:comment_date_gmt>
+			<wp:comment_content><![CDATA[Nice <strong>post</strong>]]></wp:comment_content>
+			<wp:comment_approved><![CDATA[1]]></wp:comment_approved>
+			<wp:comment_type><![CDATA[]]></wp:comment_type>
+			<wp:comment_parent>0</wp:comment_parent>
+		</wp:comment>
+		<wp:comment>
+			<wp:comment_id>2</wp:comment_id>
+			<wp:comment_author><![CDATA[Bob]]></wp:comment_author>
+			<wp:comment_author_IP><![CDATA[1.2.3.5]]></wp:comment_author_IP>
+			<wp:comment_date><![CDATA[2015-01-02 10:00:00]]></wp:comment_date>
+			<wp:comment_date_gmt><![CDATA[2015-01-02 09:00:00]]></wp:comment_date_gmt>
+			<wp:comment_content><![CDATA[thanks]]></wp:comment_content>
+			<wp:comment_approved><![CDATA[0]]></wp:comment_approved>
+			<wp:comment_parent>1</wp:comment_parent>
+		</wp:comment>
+		<wp:comment>
+			<wp:comment_id>3</wp:comment_id>
+			<wp:comment_author><![CDATA[Spam]]></wp:comment_author>
+			<wp:comment_date_gmt><![CDATA[2015-01-02 09:00:00]]></wp:comment_date_gmt>
+			<wp:comment_content><![CDATA[buy now]]></wp:comment_content>
+			<wp:comment_approved><![CDATA[spam]]></wp:comment_approved>
+		</wp:comment>
+		<wp:comment>
+			<wp:comment_id>4</wp:comment_id>
+			<wp:comment_date_gmt><![CDATA[2015-01-02 09:00:00]]></wp:comment_date_gmt>
+			<wp:comment_approved><![CDATA[1]]></wp:comment_approved>
+			<wp:comment_type><![CDATA[pingback]]></wp:comment_type>
+		</wp:comment>
+	</item>
+</channel>
+</rss>`
+
+func TestFromWordPress(t *testing.T) {
+	threads, err := FromWordPress(strings.NewReader(wordpressXML))
+	if err != nil {
+		t.Fatalf("FromWordPress() error = %v", err)
+	}
+	if len(threads) != 1 {
+		t.Fatalf("FromWordPress() got %d threads, want 1", len(threads))
+	}
+	thread := threads[0]
+	if thread.URI != "/2015/01/hello-world/" || thread.Title != "Hello World" || len(thread.Comments) != 2 {
+		t.Fatalf("FromWordPress() thread = %+v", thread)
+	}
+	approved := thread.Comments[0]
+	if approved.Mode != isso.ModeAccepted || approved.Text != "Nice **post**" || approved.Author != "Alice" ||
+		approved.Website == nil || *approved.Website != "https://alice.example.com" || approved.Created != 1420102800 {
+		t.Errorf("FromWordPress() approved comment = %+v", approved)
+	}
+	pending := thread.Comments[1]
+	if pending.Mode != isso.ModeModeration || pending.Parent != "1" || pending.Email != nil {
+		t.Errorf("FromWordPress() pending comment = %+v", pending)
+	}
+}
+
+const wordpressPlainPermalinkXML = `<?xml version="1.0" encoding="UTF-8" ?>
+<rss version="2.0" xmlns:wp="http://wordpress.org/export/1.2/">
+<channel>
+	<item>
+		<title>First</title>
+		<link>https://blog.example.com/?p=12</link>
+		<wp:comment>
+			<wp:comment_id>1</wp:comment_id>
+			<wp:comment_date_gmt><![CDATA[2015-01-01 09:00:00]]></wp:comment_date_gmt>
+			<wp:comment_content><![CDATA[first]]></wp:comment_content>
+			<wp:comment_approved><![CDATA[1]]></wp:comment_approved>
+		</wp:comment>
+	</item>
+	<item>
+		<title>Second</title>
+		<link>https://blog.example.com/?p=13</link>
+		<wp:comment>
+			<wp:comment_id>2</wp:comment_id>
+			<wp:comment_date_gmt><![CDATA[2015-01-02 09:00:00]]></wp:comment_date_gmt>
+			<wp:comment_content><![CDATA[second]]></wp:comment_content>
+			<wp:comment_approved><![CDATA[1]]></wp:comment_approved>
+		</wp:comment>
+	</item>
+</channel>
+</rss>`
+
+func TestFromWordPress_plainPermalinks(t *testing.T) {
+	threads, err := FromWordPress(strings.NewReader(wordpressPlainPermalinkXML))
+	if err != nil {
+		t.Fatalf("FromWordPress() error = %v", err)
+	}
+	if len(threads) != 2 {
+		t.Fatalf("FromWordPress() got %d threads, want 2", len(threads))
+	}
+	for i, want := range []string{"/?p=12", "/?p=13"} {
+		if threads[i].URI != want || len(threads[i].Comments) != 1 {
+			t.Errorf("FromWordPress() thread %d = %+v, want uri %s with 1 comment", i, threads[i], want)
+		}
+	}
+}
@@ -0,0 +1,276 @@
+package isso
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/schema"
+	"wrong.wang/x/go-isso/logger"
+)
+
+const (
+	adminSessionName   = "admin-session"
+	adminSessionMaxAge = 24 * 3600
+	adminPageSize      = 100
+)
+
+// AdminLogin check admin password and set session cookie.
+func (isso *ISSO) AdminLogin() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !isso.config().Admin.Enable {
+			http.NotFound(w, r)
+			return
+		}
+		password := r.PostFormValue("password")
+		if password == "" || subtle.ConstantTimeCompare([]byte(password), []byte(isso.config().Admin.Password)) != 1 {
+			logger.FromContext(r.Context()).Error("admin login failed from %s", isso.findClientIP(r))
+			w.WriteHeader(http.StatusForbidden)
+			isso.renderAdmin(w, r, "login", map[string]string{
+				"Endpoint": isso.publicEndpoint(r),
+				"Message":  "wrong password",
+			})
+			return
+		}
+
+		expires := time.Now().Add(adminSessionMaxAge * time.Second).Unix()
+		encoded, err := isso.tools.securecookie.Encode(adminSessionName, expires)
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		http.SetCookie(w, &http.Cookie{
+			Name:     adminSessionName,
+			Value:    encoded,
+			Path:     isso.cookiePath(r),
+			MaxAge:   adminSessionMaxAge,
+			HttpOnly: true,
+			SameSite: http.SameSiteStrictMode,
+		})
+		http.Redirect(w, r, isso.publicEndpoint(r)+"/admin", http.StatusSeeOther)
+	}
+}
+
+// AdminDashboard list comments of all threads, filtered by mode.
+// Show the login form when requestor is not logged in.
+func (isso *ISSO) AdminDashboard() http.HandlerFunc {
+	type urlParm struct {
+		Mode    int    `schema:"mode"`
+		OrderBy string `schema:"order_by"`
+		Asc     int    `schema:"asc"`
+		Page    int64  `schema:"page"`
+	}
+	type adminComment struct {
+		Comment Comment
+		Key     string
+		Created string
+	}
+	type adminThread struct {
+		Thread   Thread
+		Link     string
+		Comments []adminComment
+	}
+	type adminMode struct {
+		Mode  int
+		Name  string
+		Count int64
+	}
+	decoder := schema.NewDecoder()
+	decoder.IgnoreUnknownKeys(true)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !isso.config().Admin.Enable {
+			http.NotFound(w, r)
+			return
+		}
+		endpoint := isso.publicEndpoint(r)
+		if !isso.isAdmin(r) {
+			isso.renderAdmin(w, r, "login", map[string]string{"Endpoint": endpoint})
+			return
+		}
+
+		urlparm := urlParm{Mode: ModeAccepted, OrderBy: "created"}
+		if err := decoder.Decode(&urlparm, r.URL.Query()); err != nil {
+			http.Error(w, descRequestInvalidParm, http.StatusBadRequest)
+			return
+		}
+		switch urlparm.Mode {
+		case ModeAccepted, ModeModeration, ModeDeleted:
+		default:
+			urlparm.Mode = ModeAccepted
+		}
+		if urlparm.Page < 0 {
+			urlparm.Page = 0
+		}
+
+		counts, err := isso.storage.CountCommentsByMode(r.Context())
+		if err != nil {
+			isso.adminServerError(w, r, err)
+			return
+		}
+		// fetch one more comment to know whether next page exists.
+		comments, err := isso.storage.FetchComments(r.Context(), urlparm.Mode, urlparm.OrderBy, urlparm.Asc != 0,
+			adminPageSize+1, urlparm.Page*adminPageSize)
+		if err != nil {
+			isso.adminServerError(w, r, err)
+			return
+		}
+		hasNext := len(comments) > adminPageSize
+		if hasNext {
+			comments = comments[:adminPageSize]
+		}
+
+		var threads []*adminThread
+		threadByID := map[int64]*adminThread{}
+		for _, c := range comments {
+			t, ok := threadByID[c.TID]
+			if !ok {
+				thread, err := isso.storage.GetThreadByID(r.Context(), c.TID)
+				if err != nil {
+					isso.adminServerError(w, r, err)
+					return
+				}
+				t = &adminThread{Thread: thread, Link: isso.threadLink(thread)}
+				threadByID[c.TID] = t
+				threads = append(threads, t)
+			}
+			key, err := isso.ModerationKey(c.ID)
+			if err != nil {
+				isso.adminServerError(w, r, err)
+				return
+			}
+			t.Comments = append(t.Comments, adminComment{
+				Comment: c,
+				Key:     key,
+				Created: time.Unix(int64(c.Created), 0).Format("2006-01-02 15:04"),
+			})
+		}
+
+		isso.renderAdmin(w, r, "dashboard", struct {
+			Endpoint string
+			Mode     int
+			OrderBy  string
+			Asc      int
+			Page     int64
+			PrevPage int64
+			NextPage int64
+			HasNext  bool
+			Modes    []adminMode
+			Orders   []string
+			Threads  []*adminThread
+		}{
+			Endpoint: endpoint,
+			Mode:     urlparm.Mode,
+			OrderBy:  urlparm.OrderBy,
+			Asc:      urlparm.Asc,
+			Page:     urlparm.Page,
+			PrevPage: urlparm.Page - 1,
+			NextPage: urlparm.Page + 1,
+			HasNext:  hasNext,
+			Modes: []adminMode{
+				{ModeAccepted, "public", counts[ModeAccepted]},
+				{ModeModeration, "pending", counts[ModeModeration]},
+				{ModeDeleted, "deleted", counts[ModeDeleted]},
+			},
+			Orders:  []string{"created", "modified", "likes", "dislikes", "tid"},
+			Threads: threads,
+		})
+	}
+}
+
+// AdminBulkModerate approve or delete all selected comments, then go back to dashboard.
+func (isso *ISSO) AdminBulkModerate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !isso.config().Admin.Enable {
+			http.NotFound(w, r)
+			return
+		}
+		if !isso.isAdmin(r) {
+			http.Error(w, descRequestInvalidCookies, http.StatusForbidden)
+			return
+		}
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, descRequestInvalidParm, http.StatusBadRequest)
+			return
+		}
+		action := r.PostForm.Get("action")
+		if action != "activate" && action != "delete" {
+			http.Error(w, descRequestInvalidParm, http.StatusBadRequest)
+			return
+		}
+		for _, v := range r.PostForm["id"] {
+			id, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				http.Error(w, descRequestInvalidParm, http.StatusBadRequest)
+				return
+			}
+			if err := isso.moderate(r.Context(), id, action); err != nil {
+				isso.adminServerError(w, r, err)
+				return
+			}
+		}
+		http.Redirect(w, r, isso.publicEndpoint(r)+"/admin?"+r.URL.RawQuery, http.StatusSeeOther)
+	}
+}
+
+// moderate activate or delete comment `id` and publish the event.
+func (isso *ISSO) moderate(ctx context.Context, id int64, action string) error {
+	comment, err := isso.storage.GetComment(ctx, id)
+	if err != nil {
+		if errors.Is(err, ErrStorageNotFound) {
+			// already deleted, maybe by a previous bulk action.
+			return nil
+		}
+		return err
+	}
+	switch action {
+	case "activate":
+		if comment.Mode != ModeModeration {
+			return nil
+		}
+		thread, err := isso.storage.GetThreadByID(ctx, comment.TID)
+		if err != nil {
+			return err
+		}
+		if err := isso.storage.ActivateComment(ctx, id); err != nil {
+			return err
+		}
+		isso.tools.event.Publish("comments.activate", logger.FromContext(ctx), thread, comment)
+	case "delete":
+		if _, err := isso.storage.DeleteComment(ctx, id); err != nil {
+			return err
+		}
+		isso.tools.event.Publish("comments.delete", logger.FromContext(ctx), id)
+	default:
+		return fmt.Errorf("unknown moderate action %s", action)
+	}
+	return nil
+}
+
+func (isso *ISSO) isAdmin(r *http.Request) bool {
+	cookie, err := r.Cookie(adminSessionName)
+	if err != nil {
+		return false
+	}
+	var expires int64
+	if err := isso.tools.securecookie.Decode(adminSessionName, cookie.Value, &expires); err != nil {
+		return false
+	}
+	return time.Now().Unix() < expires
+}
+
+func (isso *ISSO) renderAdmin(w http.ResponseWriter, r *http.Request, name string, data interface{}) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := adminTemplate.ExecuteTemplate(w, name, data); err != nil {
+		logger.FromContext(r.Context()).Error("render admin template %s failed: %v", name, err)
+	}
+}
+
+func (isso *ISSO) adminServerError(w http.ResponseWriter, r *http.Request, err error) {
+	logger.FromContext(r.Context()).With("error", err).Error("%s", descStorageUnhandledError)
+	http.Error(w, descStorageUnhandledError, http.StatusInternalServerError)
+}
@@ -0,0 +1,98 @@
+package isso
+
+import "html/template"
+
+var adminTemplate = template.Must(template.New("admin").Funcs(template.FuncMap{
+	"deref": func(s *string) string {
+		if s == nil {
+			return ""
+		}
+		return *s
+	},
+}).Parse(`{{define "header"}}<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>go-isso admin</title>
+<style>
+	body { font-family: sans-serif; max-width: 960px; margin: 0 auto; padding: 1em; color: #333; }
+	nav a, nav span { margin-right: 1em; }
+	nav .current { font-weight: bold; }
+	.thread { margin-top: 2em; }
+	.comment { border-left: 3px solid #ddd; padding: .5em 1em; margin: 1em 0; }
+	.comment.mode-2 { border-color: #e8a33d; }
+	.comment.mode-4 { border-color: #c33; color: #999; }
+	.meta span { margin-right: 1em; }
+	.text { white-space: pre-wrap; margin: .5em 0; }
+	.editable { background: #ffe; outline: 1px dashed #aaa; }
+	.hidden { display: none; }
+	.message { color: #c33; }
+</style>
+</head>
+<body>
+{{end}}
+
+{{define "login"}}{{template "header"}}
+<h1>go-isso admin</h1>
+{{if .Message}}<p class="message">{{.Message}}</p>{{end}}
+<form method="POST" action="{{.Endpoint}}/login">
+	<input type="password" name="password" placeholder="password" autofocus>
+	<input type="submit" value="Log in">
+</form>
+</body>
+</html>
+{{end}}
+
+{{define "dashboard"}}{{template "header"}}
+<script src="{{.Endpoint}}/js/admin.js"></script>
+<h1>go-isso admin</h1>
+<nav>
+	{{range .Modes}}<a href="?mode={{.Mode}}&amp;order_by={{$.OrderBy}}&amp;asc={{$.Asc}}"{{if eq .Mode $.Mode}} class="current"{{end}}>{{.Name}} ({{.Count}})</a>{{end}}
+</nav>
+<nav>
+	<span>order by:</span>
+	{{range .Orders}}<a href="?mode={{$.Mode}}&amp;order_by={{.}}&amp;asc={{$.Asc}}"{{if eq . $.OrderBy}} class="current"{{end}}>{{.}}</a>{{end}}
+	<a href="?mode={{.Mode}}&amp;order_by={{.OrderBy}}&amp;asc={{if .Asc}}0{{else}}1{{end}}">{{if .Asc}}asc{{else}}desc{{end}}</a>
+</nav>
+<form method="POST" action="{{.Endpoint}}/admin?mode={{.Mode}}&amp;order_by={{.OrderBy}}&amp;asc={{.Asc}}&amp;page={{.Page}}">
+	<p>
+		<button type="submit" name="action" value="activate">Approve selected</button>
+		<button type="submit" name="action" value="delete">Delete selected</button>
+	</p>
+	{{range .Threads}}
+	<div class="thread">
+		<h2><a href="{{.Link}}">{{.Thread.Title}}</a> <small>{{.Thread.URI}}</small></h2>
+		{{range .Comments}}
+		<div class="comment mode-{{.Comment.Mode}}" id="isso-{{.Comment.ID}}">
+			<div class="meta">
+				<input type="checkbox" name="id" value="{{.Comment.ID}}">
+				<span>#{{.Comment.ID}}</span>
+				<span id="isso-author-{{.Comment.ID}}">{{.Comment.Author}}</span>
+				<span id="isso-email-{{.Comment.ID}}">{{deref .Comment.Email}}</span>
+				<span id="isso-website-{{.Comment.ID}}">{{deref .Comment.Website}}</span>
+				<span>{{.Created}}</span>
+				<span>{{.Comment.RemoteAddr}}</span>
+				<span>+{{.Comment.Likes}} / -{{.Comment.Dislikes}}</span>
+			</div>
+			<div class="text" id="isso-text-{{.Comment.ID}}">{{.Comment.Text}}</div>
+			<div class="actions">
+				{{if eq .Comment.Mode 2}}<button type="button" onclick="validate_com({{.Comment.ID}}, {{.Key}}, {{$.Endpoint}})">Approve</button>{{end}}
+				<button type="button" onclick="delete_com({{.Comment.ID}}, {{.Key}}, {{$.Endpoint}})">Delete</button>
+				<button type="button" id="edit-btn-{{.Comment.ID}}" onclick="start_edit({{.Comment.ID}})">Edit</button>
+				<button type="button" id="stop-edit-btn-{{.Comment.ID}}" class="hidden" onclick="stop_edit({{.Comment.ID}})">Cancel</button>
+				<button type="button" id="send-edit-btn-{{.Comment.ID}}" class="hidden" onclick="send_edit({{.Comment.ID}}, {{.Key}}, {{$.Endpoint}})">Save</button>
+			</div>
+		</div>
+		{{end}}
+	</div>
+	{{else}}
+	<p>no comments.</p>
+	{{end}}
+</form>
+<nav>
+	{{if gt .Page 0}}<a href="?mode={{.Mode}}&amp;order_by={{.OrderBy}}&amp;asc={{.Asc}}&amp;page={{.PrevPage}}">previous</a>{{end}}
+	{{if .HasNext}}<a href="?mode={{.Mode}}&amp;order_by={{.OrderBy}}&amp;asc={{.Asc}}&amp;page={{.NextPage}}">next</a>{{end}}
+</nav>
+</body>
+</html>
+{{end}}`))
@@ -0,0 +1,114 @@
+package isso
+
+import (
+	"crypto/md5"
+	"fmt"
+	"math"
+	"math/big"
+	"net/http"
+	"strings"
+	"unicode"
+
+	"github.com/gorilla/mux"
+)
+
+// convert comment to reply, with gravatar image if enabled.
+func (isso *ISSO) convert(c Comment, plain bool) (reply, error) {
+	var image string
+	if isso.config().Gravatar {
+		image = isso.gravatar(c)
+	}
+	r, err := c.convert(plain, isso.tools.hash, isso.markdown())
+	r.GravatarImage = image
+	return r, err
+}
+
+// gravatar build image url with MD5 of email, commenter without email
+// get the default image of template, identicon by default.
+func (isso *ISSO) gravatar(c Comment) string {
+	var seed string
+	if c.Email != nil && *c.Email != "" {
+		seed = strings.ToLower(strings.TrimSpace(*c.Email))
+	} else {
+		// an identity not exists in gravatar, but stable for the commenter.
+		seed = isso.tools.hash.Hash(c.RemoteAddr)
+	}
+	return strings.Replace(isso.config().GravatarURL, "{}", fmt.Sprintf("%x", md5.Sum([]byte(seed))), 1)
+}
+
+// identicon colors, same as the defaults of js client.
+var (
+	identiconBackground = "#f0f0f0"
+	identiconForeground = []string{"#9abf88", "#5698c4", "#e279a3", "#9163b6", "#be5168", "#f19670", "#e4bf80", "#447c69"}
+)
+
+const (
+	identiconGrid    = 5
+	identiconPadding = 4
+	identiconSize    = 48
+	identiconCell    = 8
+)
+
+// Avatar render the identicon of hash as SVG, same as js/app/lib/identicons.js.
+func (isso *ISSO) Avatar() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		hash := mux.Vars(r)["hash"]
+		w.Header().Set("Content-Type", "image/svg+xml")
+		w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+		w.Header().Set("ETag", `"`+hash+`"`)
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		if r.Header.Get("If-None-Match") == `"`+hash+`"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Write([]byte(identicon(hash)))
+	}
+}
+
+// identicon draw a 5x5 symmetric grid with 15 bits of the hash, and pick color with last 3 bits.
+func identicon(hash string) string {
+	var b strings.Builder
+	fill := func(x, y, padding, size int, color string) {
+		fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%d" height="%d" style="fill: %s"/>`,
+			padding+x*size, padding+y*size, size, size, color)
+	}
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" version="1.1" width="%d" height="%d" viewBox="0 0 %d %d" `+
+		`preserveAspectRatio="xMinYMin meet" shape-rendering="crispEdges" data-hash="%s">`,
+		identiconSize, identiconSize, identiconSize, identiconSize, hash)
+	fill(0, 0, 0, identiconSize+2*identiconPadding, identiconBackground)
+
+	bits := identiconBits(hash)
+	color := identiconForeground[int(bits&7)%len(identiconForeground)]
+	index := 0
+	for x := 0; x < (identiconGrid+1)/2; x++ {
+		for y := 0; y < identiconGrid; y++ {
+			// bits are read from the most significant of 18.
+			if bits&(1<<uint(17-index)) != 0 {
+				fill(x, y, identiconPadding, identiconCell, color)
+				if x < identiconGrid/2 {
+					fill(identiconGrid-1-x, y, identiconPadding, identiconCell, color)
+				}
+			}
+			index++
+		}
+	}
+	b.WriteString("</svg>")
+	return b.String()
+}
+
+// identiconBits is `parseInt(key.substr(-16), 16) % Math.pow(2, 18)` in js,
+// which parse the leading hex digits as float64, and NaN draws nothing.
+func identiconBits(hash string) uint64 {
+	if len(hash) > 16 {
+		hash = hash[len(hash)-16:]
+	}
+	if end := strings.IndexFunc(hash, func(r rune) bool { return !unicode.Is(unicode.ASCII_Hex_Digit, r) }); end >= 0 {
+		hash = hash[:end]
+	}
+	n, ok := new(big.Int).SetString(hash, 16)
+	if !ok {
+		return 0
+	}
+	f, _ := new(big.Float).SetInt(n).Float64()
+	return uint64(math.Mod(f, 1<<18))
+}
@@ -0,0 +1,106 @@
+package isso
+
+import (
+	"testing"
+
+	"wrong.wang/x/go-isso/config"
+	"wrong.wang/x/go-isso/tool/hash"
+)
+
+// svgHeader is the start of every identicon, with the background.
+func svgHeader(hash string) string {
+	return `<svg xmlns="http://www.w3.org/2000/svg" version="1.1" width="48" height="48" viewBox="0 0 48 48" ` +
+		`preserveAspectRatio="xMinYMin meet" shape-rendering="crispEdges" data-hash="` + hash + `">` +
+		`<rect x="0" y="0" width="56" height="56" style="fill: #f0f0f0"/>`
+}
+
+// Test_identicon golden files are drawn by the algorithm of js/app/lib/identicons.js.
+func Test_identicon(t *testing.T) {
+	tests := []struct {
+		hash  string
+		rects string
+	}{
+		// only the last 16 hex digits are used, parsed as float64.
+		{"d41d8cd98f00b204e9800998ecf8427e",
+			`<rect x="4" y="28" width="8" height="8" style="fill: #9abf88"/>` +
+				`<rect x="36" y="28" width="8" height="8" style="fill: #9abf88"/>`},
+		{"9a0364b9e99bb480dd25e1f0284c8555",
+			`<rect x="4" y="20" width="8" height="8" style="fill: #9abf88"/>` +
+				`<rect x="36" y="20" width="8" height="8" style="fill: #9abf88"/>` +
+				`<rect x="12" y="12" width="8" height="8" style="fill: #9abf88"/>` +
+				`<rect x="28" y="12" width="8" height="8" style="fill: #9abf88"/>`},
+		// shorter than 16 hex digits.
+		{"a", `<rect x="20" y="36" width="8" height="8" style="fill: #e279a3"/>`},
+		{"1f",
+			`<rect x="20" y="28" width="8" height="8" style="fill: #447c69"/>` +
+				`<rect x="20" y="36" width="8" height="8" style="fill: #447c69"/>`},
+		// parseInt stop at the first non-hex character.
+		{"12zz", `<rect x="20" y="28" width="8" height="8" style="fill: #e279a3"/>`},
+		// NaN draws nothing.
+		{"xyz", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		want := svgHeader(tt.hash) + tt.rects + "</svg>"
+		if got := identicon(tt.hash); got != want {
+			t.Errorf("identicon(%q) got\n%s\nwant\n%s", tt.hash, got, want)
+		}
+	}
+}
+
+func Test_identiconBits(t *testing.T) {
+	tests := []struct {
+		hash string
+		want uint64
+	}{
+		{"d41d8cd98f00b204e9800998ecf8427e", 16384},
+		{"9a0364b9e99bb480dd25e1f0284c8555", 34816},
+		{"a", 10},
+		{"3ffff", 1<<18 - 1},
+		{"40000", 0},
+		{"12zz", 18},
+		{"xyz", 0},
+	}
+	for _, tt := range tests {
+		if got := identiconBits(tt.hash); got != tt.want {
+			t.Errorf("identiconBits(%q) = %v, want %v", tt.hash, got, tt.want)
+		}
+	}
+}
+
+func TestISSO_gravatar(t *testing.T) {
+	hashWorker, err := hash.New("sha1", "salt")
+	if err != nil {
+		t.Fatalf("hash.New() error = %v", err)
+	}
+	isso := &ISSO{tools: tools{hash: hashWorker}}
+	if err := isso.Reload(config.Config{GravatarURL: "https://www.gravatar.com/avatar/{}?d=identicon"}); err != nil {
+		t.Fatalf("ISSO.Reload() error = %v", err)
+	}
+
+	email := " MyEmailAddress@example.com "
+	empty := ""
+	tests := []struct {
+		name string
+		c    Comment
+		want string
+	}{
+		// the example of gravatar documents.
+		{"email", Comment{Email: &email, RemoteAddr: "127.0.0.1"},
+			"https://www.gravatar.com/avatar/0bc83cb571cd1c50ba6f3e8a78ef1346?d=identicon"},
+		// md5 of sha1("salt" + remote address).
+		{"no email", Comment{RemoteAddr: "127.0.0.1"},
+			"https://www.gravatar.com/avatar/4be0c341ce9b692625eb3ff8f640556f?d=identicon"},
+		{"empty email", Comment{Email: &empty, RemoteAddr: "127.0.0.1"},
+			"https://www.gravatar.com/avatar/4be0c341ce9b692625eb3ff8f640556f?d=identicon"},
+		{"other address", Comment{RemoteAddr: "127.0.0.2"},
+			"https://www.gravatar.com/avatar/dd8f15474ed04bdb358383ea8a45b702?d=identicon"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isso.gravatar(tt.c); got != tt.want {
+				t.Errorf("ISSO.gravatar() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
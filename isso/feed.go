@@ -0,0 +1,302 @@
+package isso
+
+import (
+	stdjson "encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+
+	"wrong.wang/x/go-isso/response/json"
+)
+
+// feedLimit is the max amount of entries in a feed.
+const feedLimit = 100
+
+type feed struct {
+	ID      string
+	Title   string
+	Home    string
+	Self    string
+	Updated time.Time
+	Entries []feedEntry
+}
+
+type feedEntry struct {
+	Link      string
+	Title     string
+	Author    string
+	Website   string
+	Content   string
+	Published time.Time
+	Updated   time.Time
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+	URI  string `xml:"uri,omitempty"`
+}
+
+type atomContent struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+type atomEntry struct {
+	ID        string      `xml:"id"`
+	Title     string      `xml:"title"`
+	Link      atomLink    `xml:"link"`
+	Author    atomPerson  `xml:"author"`
+	Published string      `xml:"published"`
+	Updated   string      `xml:"updated"`
+	Content   atomContent `xml:"content"`
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type jsonFeedAuthor struct {
+	Name string `json:"name"`
+	URL  string `json:"url,omitempty"`
+}
+
+type jsonFeedItem struct {
+	ID            string           `json:"id"`
+	URL           string           `json:"url"`
+	Title         string           `json:"title"`
+	ContentHTML   string           `json:"content_html"`
+	DatePublished string           `json:"date_published"`
+	DateModified  string           `json:"date_modified,omitempty"`
+	Authors       []jsonFeedAuthor `json:"authors"`
+}
+
+type jsonFeed struct {
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	HomePageURL string         `json:"home_page_url"`
+	FeedURL     string         `json:"feed_url"`
+	Items       []jsonFeedItem `json:"items"`
+}
+
+// Feed render public comments of a thread, or of all threads without `uri`,
+// as Atom, or JSON Feed with `format=json`.
+func (isso *ISSO) Feed() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		requestID := RequestIDFromContext(r.Context())
+		query := r.URL.Query()
+		format := query.Get("format")
+		if format != "" && format != "atom" && format != "json" {
+			json.BadRequest(requestID, w, nil, descRequestInvalidParm)
+			return
+		}
+
+		var f feed
+		var err error
+		if uri := query.Get("uri"); uri != "" {
+			f, err = isso.threadFeed(r, uri)
+		} else {
+			f, err = isso.siteFeed(r)
+		}
+		if err != nil {
+			if errors.Is(err, ErrStorageNotFound) {
+				json.NotFound(requestID, w, err, descStorageNotFound)
+				return
+			}
+			json.ServerError(requestID, w, err, descStorageUnhandledError)
+			return
+		}
+
+		if format == "json" {
+			f.writeJSONFeed(w)
+			return
+		}
+		f.writeAtom(w)
+	}
+}
+
+func (isso *ISSO) threadFeed(r *http.Request, uri string) (feed, error) {
+	thread, err := isso.storage.GetThreadByURI(r.Context(), uri)
+	if err != nil {
+		return feed{}, err
+	}
+	commentsByParent, err := isso.storage.FetchCommentsByURI(r.Context(), uri, -1, ModeAccepted, "created", false)
+	if err != nil {
+		return feed{}, err
+	}
+	comments := []Comment{}
+	for _, cs := range commentsByParent {
+		comments = append(comments, cs...)
+	}
+	sort.Slice(comments, func(i, j int) bool { return comments[i].Created > comments[j].Created })
+	if len(comments) > feedLimit {
+		comments = comments[:feedLimit]
+	}
+
+	base := isso.feedBase(r)
+	f := feed{
+		Title: fmt.Sprintf("Comments on %s", thread.Title),
+		Home:  base + thread.URI,
+		Self:  isso.publicEndpoint(r) + "/feed?uri=" + url.QueryEscape(uri),
+	}
+	for _, c := range comments {
+		f.add(isso.feedEntry(base, thread, c))
+	}
+	return f, nil
+}
+
+func (isso *ISSO) siteFeed(r *http.Request) (feed, error) {
+	comments, err := isso.storage.FetchComments(r.Context(), ModeAccepted, "created", false, feedLimit, 0)
+	if err != nil {
+		return feed{}, err
+	}
+
+	base := isso.feedBase(r)
+	f := feed{
+		Title: fmt.Sprintf("Comments on %s", base),
+		Home:  base + "/",
+		Self:  isso.publicEndpoint(r) + "/feed",
+	}
+	threads := map[int64]Thread{}
+	for _, c := range comments {
+		thread, ok := threads[c.TID]
+		if !ok {
+			thread, err = isso.storage.GetThreadByID(r.Context(), c.TID)
+			if errors.Is(err, ErrStorageNotFound) {
+				continue
+			}
+			if err != nil {
+				return feed{}, err
+			}
+			threads[c.TID] = thread
+		}
+		entry := isso.feedEntry(base, thread, c)
+		entry.Title = fmt.Sprintf("%s on %s", entry.Title, thread.Title)
+		f.add(entry)
+	}
+	return f, nil
+}
+
+// feedBase return scheme and host of public endpoint, which thread URIs are relative to.
+func (isso *ISSO) feedBase(r *http.Request) string {
+	endpoint := isso.publicEndpoint(r)
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return endpoint
+	}
+	return u.Scheme + "://" + u.Host
+}
+
+func (isso *ISSO) feedEntry(base string, thread Thread, c Comment) feedEntry {
+	reply, _ := c.convert(false, isso.tools.hash, isso.markdown())
+	entry := feedEntry{
+		Link:      fmt.Sprintf("%s%s#isso-%d", base, thread.URI, c.ID),
+		Title:     "Anonymous",
+		Author:    "Anonymous",
+		Content:   reply.Text,
+		Published: floatTime(c.Created),
+		Updated:   floatTime(c.Created),
+	}
+	if c.Author != "" {
+		entry.Title = c.Author
+		entry.Author = c.Author
+	}
+	if c.Website != nil {
+		entry.Website = *c.Website
+	}
+	if c.Modified != nil {
+		entry.Updated = floatTime(*c.Modified)
+	}
+	return entry
+}
+
+func (f *feed) add(entry feedEntry) {
+	if entry.Updated.After(f.Updated) {
+		f.Updated = entry.Updated
+	}
+	f.Entries = append(f.Entries, entry)
+}
+
+func (f feed) writeAtom(w http.ResponseWriter) {
+	updated := f.Updated
+	if updated.IsZero() {
+		updated = time.Now()
+	}
+	af := atomFeed{
+		ID:      f.Self,
+		Title:   f.Title,
+		Updated: updated.UTC().Format(time.RFC3339),
+		Links: []atomLink{
+			{Href: f.Home, Rel: "alternate", Type: "text/html"},
+			{Href: f.Self, Rel: "self", Type: "application/atom+xml"},
+		},
+	}
+	for _, e := range f.Entries {
+		af.Entries = append(af.Entries, atomEntry{
+			ID:        e.Link,
+			Title:     e.Title,
+			Link:      atomLink{Href: e.Link, Rel: "alternate"},
+			Author:    atomPerson{Name: e.Author, URI: e.Website},
+			Published: e.Published.UTC().Format(time.RFC3339),
+			Updated:   e.Updated.UTC().Format(time.RFC3339),
+			Content:   atomContent{Type: "html", Body: e.Content},
+		})
+	}
+	w.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+	w.Write([]byte(xml.Header))
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	encoder.Encode(af)
+}
+
+func (f feed) writeJSONFeed(w http.ResponseWriter) {
+	jf := jsonFeed{
+		Version:     "https://jsonfeed.org/version/1.1",
+		Title:       f.Title,
+		HomePageURL: f.Home,
+		FeedURL:     f.Self + feedFormatParam(f.Self),
+		Items:       []jsonFeedItem{},
+	}
+	for _, e := range f.Entries {
+		item := jsonFeedItem{
+			ID:            e.Link,
+			URL:           e.Link,
+			Title:         e.Title,
+			ContentHTML:   e.Content,
+			DatePublished: e.Published.UTC().Format(time.RFC3339),
+			Authors:       []jsonFeedAuthor{{Name: e.Author, URL: e.Website}},
+		}
+		if !e.Updated.Equal(e.Published) {
+			item.DateModified = e.Updated.UTC().Format(time.RFC3339)
+		}
+		jf.Items = append(jf.Items, item)
+	}
+	w.Header().Set("Content-Type", "application/feed+json; charset=utf-8")
+	stdjson.NewEncoder(w).Encode(jf)
+}
+
+func feedFormatParam(self string) string {
+	if u, err := url.Parse(self); err == nil && u.RawQuery != "" {
+		return "&format=json"
+	}
+	return "?format=json"
+}
+
+func floatTime(t float64) time.Time {
+	sec := int64(t)
+	return time.Unix(sec, int64((t-float64(sec))*1e9))
+}
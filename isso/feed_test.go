@@ -0,0 +1,125 @@
+package isso
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testFeed() feed {
+	published := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	var f feed
+	f.Title = "Comments on Post"
+	f.Home = "https://example.com/post"
+	f.Self = "https://example.com/isso/feed?uri=%2Fpost"
+	f.add(feedEntry{
+		Link:      "https://example.com/post#isso-2",
+		Title:     "b",
+		Author:    "b",
+		Website:   "https://b.example.com",
+		Content:   "<p>edited &amp; <em>new</em></p>",
+		Published: published.Add(time.Hour),
+		Updated:   published.Add(2 * time.Hour),
+	})
+	f.add(feedEntry{
+		Link:      "https://example.com/post#isso-1",
+		Title:     "Anonymous",
+		Author:    "Anonymous",
+		Content:   "<p>first</p>",
+		Published: published,
+		Updated:   published,
+	})
+	return f
+}
+
+func Test_feed_add(t *testing.T) {
+	f := testFeed()
+	if want := time.Date(2020, 1, 2, 5, 4, 5, 0, time.UTC); !f.Updated.Equal(want) || len(f.Entries) != 2 {
+		t.Errorf("feed.add() updated = %v with %d entries, want %v with 2 entries", f.Updated, len(f.Entries), want)
+	}
+}
+
+func Test_feed_writeAtom(t *testing.T) {
+	w := httptest.NewRecorder()
+	testFeed().writeAtom(w)
+	if ct := w.Header().Get("Content-Type"); ct != "application/atom+xml; charset=utf-8" {
+		t.Errorf("feed.writeAtom() Content-Type = %s", ct)
+	}
+	want := `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <id>https://example.com/isso/feed?uri=%2Fpost</id>
+  <title>Comments on Post</title>
+  <updated>2020-01-02T05:04:05Z</updated>
+  <link href="https://example.com/post" rel="alternate" type="text/html"></link>
+  <link href="https://example.com/isso/feed?uri=%2Fpost" rel="self" type="application/atom+xml"></link>
+  <entry>
+    <id>https://example.com/post#isso-2</id>
+    <title>b</title>
+    <link href="https://example.com/post#isso-2" rel="alternate"></link>
+    <author>
+      <name>b</name>
+      <uri>https://b.example.com</uri>
+    </author>
+    <published>2020-01-02T04:04:05Z</published>
+    <updated>2020-01-02T05:04:05Z</updated>
+    <content type="html">&lt;p&gt;edited &amp;amp; &lt;em&gt;new&lt;/em&gt;&lt;/p&gt;</content>
+  </entry>
+  <entry>
+    <id>https://example.com/post#isso-1</id>
+    <title>Anonymous</title>
+    <link href="https://example.com/post#isso-1" rel="alternate"></link>
+    <author>
+      <name>Anonymous</name>
+    </author>
+    <published>2020-01-02T03:04:05Z</published>
+    <updated>2020-01-02T03:04:05Z</updated>
+    <content type="html">&lt;p&gt;first&lt;/p&gt;</content>
+  </entry>
+</feed>`
+	if got := w.Body.String(); got != want {
+		t.Errorf("feed.writeAtom() got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func Test_feed_writeJSONFeed(t *testing.T) {
+	w := httptest.NewRecorder()
+	testFeed().writeJSONFeed(w)
+	if ct := w.Header().Get("Content-Type"); ct != "application/feed+json; charset=utf-8" {
+		t.Errorf("feed.writeJSONFeed() Content-Type = %s", ct)
+	}
+	want := `{"version":"https://jsonfeed.org/version/1.1","title":"Comments on Post",` +
+		`"home_page_url":"https://example.com/post","feed_url":"https://example.com/isso/feed?uri=%2Fpost\u0026format=json",` +
+		`"items":[{"id":"https://example.com/post#isso-2","url":"https://example.com/post#isso-2","title":"b",` +
+		`"content_html":"\u003cp\u003eedited \u0026amp; \u003cem\u003enew\u003c/em\u003e\u003c/p\u003e",` +
+		`"date_published":"2020-01-02T04:04:05Z","date_modified":"2020-01-02T05:04:05Z",` +
+		`"authors":[{"name":"b","url":"https://b.example.com"}]},` +
+		`{"id":"https://example.com/post#isso-1","url":"https://example.com/post#isso-1","title":"Anonymous",` +
+		`"content_html":"\u003cp\u003efirst\u003c/p\u003e","date_published":"2020-01-02T03:04:05Z",` +
+		`"authors":[{"name":"Anonymous"}]}]}` + "\n"
+	if got := w.Body.String(); got != want {
+		t.Errorf("feed.writeJSONFeed() got\n%s\nwant\n%s", got, want)
+	}
+
+	w = httptest.NewRecorder()
+	feed{Self: "https://example.com/feed"}.writeJSONFeed(w)
+	if want := `"items":[]`; !strings.Contains(w.Body.String(), want) {
+		t.Errorf("feed.writeJSONFeed() of empty feed = %s, want %s", w.Body.String(), want)
+	}
+}
+
+func Test_feedFormatParam(t *testing.T) {
+	tests := []struct {
+		self string
+		want string
+	}{
+		{"https://example.com/feed", "?format=json"},
+		{"https://example.com/feed?uri=%2Fpost", "&format=json"},
+		{"https://example.com/blog/feed", "?format=json"},
+	}
+	for _, tt := range tests {
+		if got := feedFormatParam(tt.self); got != tt.want {
+			t.Errorf("feedFormatParam(%q) = %v, want %v", tt.self, got, tt.want)
+		}
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/gorilla/schema"
 	"wrong.wang/x/go-isso/extract"
+	"wrong.wang/x/go-isso/logger"
 	"wrong.wang/x/go-isso/response/json"
 	"wrong.wang/x/go-isso/tool/bloomfilter"
 	"wrong.wang/x/go-isso/tool/validator"
@@ -20,6 +21,9 @@ import (
 
 const maxlikeanddislikes = 142
 
+// seconds that shared caches may keep the result of GET /count.
+const countMaxAge = 60
+
 // CreateComment create a new comment
 func (isso *ISSO) CreateComment() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -37,7 +41,7 @@ func (isso *ISSO) CreateComment() http.HandlerFunc {
 		}
 
 		comment.URI = mux.Vars(r)["uri"]
-		comment.RemoteAddr = findClientIP(r)
+		comment.RemoteAddr = isso.findClientIP(r)
 		if err := validator.Validate(comment); err != nil {
 			json.BadRequest(requestID, w, err, fmt.Sprintf("comment validate failed: %s", err.Error()))
 			return
@@ -49,6 +53,7 @@ func (isso *ISSO) CreateComment() http.HandlerFunc {
 		ok, reason := isso.newCommentGuard(r.Context(), comment.Comment, comment.URI)
 		if !ok {
 			json.Forbidden(requestID, w, nil, reason)
+			return
 		}
 
 		var thread Thread
@@ -70,7 +75,7 @@ func (isso *ISSO) CreateComment() http.HandlerFunc {
 					json.ServerError(requestID, w, err, descStorageUnhandledError)
 					return
 				}
-				isso.tools.event.Publish("comments.new:new-thread", thread)
+				isso.tools.event.Publish("comments.new:new-thread", logger.FromContext(r.Context()), thread)
 			} else {
 				// can not handled error
 				json.ServerError(requestID, w, err, descStorageUnhandledError)
@@ -80,16 +85,16 @@ func (isso *ISSO) CreateComment() http.HandlerFunc {
 
 		isso.tools.event.Publish("comments.new:before-save", thread)
 
-		if isso.config.Moderation.Enable {
-			if isso.config.Moderation.ApproveAcquaintance &&
+		if isso.config().Moderation.Enable {
+			if isso.config().Moderation.ApproveAcquaintance &&
 				comment.Email != nil &&
 				isso.storage.IsApprovedAuthor(r.Context(), *comment.Email) {
-				comment.Mode = ModePublic
-			} else {
 				comment.Mode = ModeAccepted
+			} else {
+				comment.Mode = ModeModeration
 			}
 		} else {
-			comment.Mode = ModePublic
+			comment.Mode = ModeAccepted
 		}
 		c, err := isso.storage.NewComment(r.Context(), comment.Comment, thread.ID, comment.RemoteAddr)
 		if err != nil {
@@ -98,13 +103,19 @@ func (isso *ISSO) CreateComment() http.HandlerFunc {
 		}
 		isso.tools.event.Publish("comments.new:after-save", thread, c)
 
-		reply, _ := c.convert(false, isso.tools.hash, isso.tools.markdown)
+		reply, _ := isso.convert(c, false)
+
+		isso.tools.event.Publish("comments.new:finish", logger.FromContext(r.Context()), thread, c)
 
-		isso.tools.event.Publish("comments.new:finish", thread, c)
+		if c.Mode == ModeModeration {
+			if link, err := isso.ModerationURL(isso.publicEndpoint(r), c.ID, "activate"); err == nil {
+				logger.FromContext(r.Context()).Info("comment %d awaits moderation, activate it at %s", c.ID, link)
+			}
+		}
 
-		isso.setcookie(c, w, false)
+		isso.setcookie(c, w, r, false)
 
-		if c.Mode == ModeAccepted {
+		if c.Mode == ModeModeration {
 			json.Accepted(w, reply)
 		} else {
 			json.Created(w, reply)
@@ -112,19 +123,27 @@ func (isso *ISSO) CreateComment() http.HandlerFunc {
 	}
 }
 
+// newCommentGuard check c with guard config, rejections are published
+// with `comments.new:rejected` and the reason.
 func (isso *ISSO) newCommentGuard(ctx context.Context, c Comment, uri string) (bool, string) {
-	if !isso.config.Server.Guard.Enable {
+	cfg := isso.config()
+	if !cfg.Server.Guard.Enable {
 		return true, ""
 	}
-	if isso.config.Server.Guard.RequireEmail && c.Email == nil {
-		return false, "email address required but not provided"
+	ok, reason, desc := true, "", ""
+	switch {
+	case cfg.Server.Guard.RequireEmail && c.Email == nil:
+		ok, reason, desc = false, GuardRequireEmail, "email address required but not provided"
+	case cfg.Server.Guard.RequireAuthor && c.Author == "":
+		ok, reason, desc = false, GuardRequireAuthor, "author address required but not provided"
+	default:
+		g := cfg.Server.Guard
+		ok, reason, desc = isso.storage.NewCommentGuard(ctx, c, uri, g.RateLimit, g.DirectReply, g.ReplyToSelf, cfg.MaxAge)
 	}
-	if isso.config.Server.Guard.RequireAuthor && c.Author == "" {
-		return false, "author address required but not provided"
+	if !ok {
+		isso.tools.event.Publish("comments.new:rejected", reason)
 	}
-
-	g := isso.config.Server.Guard
-	return isso.storage.NewCommentGuard(ctx, c, uri, g.RateLimit, g.DirectReply, g.ReplyToSelf, isso.config.MaxAge)
+	return ok, desc
 }
 
 // FetchComments fetch all related comments
@@ -148,7 +167,7 @@ func (isso *ISSO) FetchComments() http.HandlerFunc {
 		for _, c := range cs {
 			if c.Created > after && count < limit {
 				count++
-				r, _ := c.convert(plain, isso.tools.hash, isso.tools.markdown)
+				r, _ := isso.convert(c, plain)
 				replies = append(replies, r)
 			}
 		}
@@ -236,31 +255,87 @@ func (isso *ISSO) FetchComments() http.HandlerFunc {
 	}
 }
 
-// CountComment return every thread's comment amount
+// CountComment return comment amount of every thread in the request, in the same order.
+// uris are read from JSON body for POST, or repeated `uri` query parameters for GET
 func (isso *ISSO) CountComment() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		requestID := RequestIDFromContext(r.Context())
 		uris := []string{}
-		err := jsonBind(r.Body, &uris)
-		if err != nil {
+		if r.Method == http.MethodGet {
+			uris = r.URL.Query()["uri"]
+		} else if err := jsonBind(r.Body, &uris); err != nil {
 			json.BadRequest(requestID, w, err, descRequestInvalidParm)
 			return
 		}
 
 		counts := []int64{}
-		if len(uris) == 0 {
-			json.OK(w, counts)
+		if len(uris) > 0 {
+			countsByURI, err := isso.storage.CountComment(r.Context(), uris)
+			if err != nil {
+				json.ServerError(requestID, w, err, descStorageUnhandledError)
+				return
+			}
+			// keep the order of request.
+			for _, uri := range uris {
+				counts = append(counts, countsByURI[uri])
+			}
+		}
+		if r.Method == http.MethodGet {
+			w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", countMaxAge))
+		}
+		json.OK(w, counts)
+	}
+}
+
+// LatestComments return the most recent public comments of all threads
+func (isso *ISSO) LatestComments() http.HandlerFunc {
+	const maxLimit = 100
+	type latest struct {
+		reply
+		URI   string `json:"uri"`
+		Title string `json:"title"`
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		requestID := RequestIDFromContext(r.Context())
+		if !isso.config().LatestEnabled {
+			json.NotFound(requestID, w, nil, "latest is disabled")
+			return
+		}
+		limit, err := strconv.ParseInt(r.URL.Query().Get("limit"), 10, 64)
+		if err != nil || limit <= 0 {
+			json.BadRequest(requestID, w, err, descRequestInvalidParm)
 			return
 		}
-		countsByURI, err := isso.storage.CountComment(r.Context(), uris)
+		if limit > maxLimit {
+			limit = maxLimit
+		}
+		plain := r.URL.Query().Get("plain") == "1"
+
+		comments, err := isso.storage.FetchComments(r.Context(), ModeAccepted, "created", false, limit, 0)
 		if err != nil {
 			json.ServerError(requestID, w, err, descStorageUnhandledError)
 			return
 		}
-		for _, i := range countsByURI {
-			counts = append(counts, i)
+		threads := map[int64]Thread{}
+		result := []latest{}
+		for _, c := range comments {
+			thread, ok := threads[c.TID]
+			if !ok {
+				thread, err = isso.storage.GetThreadByID(r.Context(), c.TID)
+				if errors.Is(err, ErrStorageNotFound) {
+					// comments without thread can never be shown.
+					continue
+				}
+				if err != nil {
+					json.ServerError(requestID, w, err, descStorageUnhandledError)
+					return
+				}
+				threads[c.TID] = thread
+			}
+			reply, _ := isso.convert(c, plain)
+			result = append(result, latest{reply, thread.URI, thread.Title})
 		}
-		json.OK(w, counts)
+		json.OK(w, result)
 	}
 }
 
@@ -289,7 +364,7 @@ func (isso *ISSO) ViewComment() http.HandlerFunc {
 			return
 		}
 
-		r, _ := comment.convert(plain, isso.tools.hash, isso.tools.markdown)
+		r, _ := isso.convert(comment, plain)
 		json.OK(w, r)
 	}
 }
@@ -298,14 +373,6 @@ func (isso *ISSO) ViewComment() http.HandlerFunc {
 // Editing a comment is only possible for a short period of time after it was created and only if the requestor has a valid cookie for it.
 // Editing a comment will set a new edit cookie in the response.
 func (isso *ISSO) EditComment() http.HandlerFunc {
-
-	type editInput struct {
-		Text    string  `json:"text"  validate:"required,gte=3,lte=65535"`
-		Author  *string `json:"author"  validate:"omitempty,gte=1,lte=15"`
-		Email   *string `json:"email"  validate:"omitempty,email"`
-		Website *string `json:"website"  validate:"omitempty,url"`
-	}
-
 	return func(w http.ResponseWriter, r *http.Request) {
 		requestID := RequestIDFromContext(r.Context())
 		comment, ok := isso.checkcookies(w, r)
@@ -317,34 +384,22 @@ func (isso *ISSO) EditComment() http.HandlerFunc {
 			json.BadRequest(requestID, w, err, descRequestInvalidParm)
 			return
 		}
+		ei.omitBlank()
 		if err := validator.Validate(ei); err != nil {
 			json.BadRequest(requestID, w, err, fmt.Sprintf("edit post data validate failed: %s", err.Error()))
 			return
 		}
 
-		comment.Text = ei.Text
-		if ei.Author != nil {
-			comment.Author = *ei.Author
-		}
-		if ei.Email != nil {
-			comment.Email = ei.Email
-		}
-		if ei.Website != nil {
-			comment.Website = ei.Website
-		}
-		comment.Modified = new(float64)
-		*comment.Modified = float64(time.Now().UnixNano()) / float64(1e9)
-
-		c, err := isso.storage.EditComment(r.Context(), comment)
+		c, err := isso.storage.EditComment(r.Context(), ei.apply(comment))
 		if err != nil {
 			json.ServerError(requestID, w, err, descStorageUnhandledError)
 			return
 		}
 
-		isso.tools.event.Publish("comments.edit", c)
+		isso.tools.event.Publish("comments.edit", logger.FromContext(r.Context()), c)
 
-		reply, _ := c.convert(false, isso.tools.hash, isso.tools.markdown)
-		isso.setcookie(c, w, false)
+		reply, _ := isso.convert(c, false)
+		isso.setcookie(c, w, r, false)
 		json.OK(w, reply)
 	}
 }
@@ -392,7 +447,7 @@ func (isso *ISSO) VoteComment() http.HandlerFunc {
 			return
 		}
 
-		remoteAddr := findClientIP(r)
+		remoteAddr := isso.findClientIP(r)
 		bf := bloomfilter.RecoverFrom(c.Voters, c.Likes+c.Dislikes)
 		if bf.Contains([]byte(remoteAddr)) {
 			vr.Msg = fmt.Sprintf(`denied because a vote has already been registered for this remote address: %s`, remoteAddr)
@@ -433,10 +488,10 @@ func (isso *ISSO) DeleteComment() http.HandlerFunc {
 			return
 		}
 
-		isso.tools.event.Publish("comments.delete", comment.ID)
+		isso.tools.event.Publish("comments.delete", logger.FromContext(r.Context()), comment.ID)
 
-		reply, _ := comment.convert(false, isso.tools.hash, isso.tools.markdown)
-		isso.setcookie(comment, w, true)
+		reply, _ := isso.convert(comment, false)
+		isso.setcookie(comment, w, r, true)
 		json.OK(w, reply)
 	}
 }
@@ -454,7 +509,7 @@ func (isso *ISSO) PreviewText() http.HandlerFunc {
 			json.BadRequest(requestID, w, err, descRequestInvalidParm)
 			return
 		}
-		rendertext, _ := isso.tools.markdown.Convert(it.Text)
+		rendertext, _ := isso.markdown().Convert(it.Text)
 		json.OK(w, map[string]string{"text": rendertext})
 	}
 }
@@ -491,11 +546,12 @@ func (isso *ISSO) checkcookies(w http.ResponseWriter, r *http.Request) (Comment,
 	return Comment{}, false
 }
 
-func (isso *ISSO) setcookie(c Comment, w http.ResponseWriter, delete bool) {
+func (isso *ISSO) setcookie(c Comment, w http.ResponseWriter, r *http.Request, delete bool) {
+	path := isso.cookiePath(r)
 	if delete {
 		cookie := &http.Cookie{
 			Name:   fmt.Sprintf("%v", c.ID),
-			Path:   "/",
+			Path:   path,
 			MaxAge: -1,
 			Secure: true,
 		}
@@ -503,7 +559,7 @@ func (isso *ISSO) setcookie(c Comment, w http.ResponseWriter, delete bool) {
 
 		cookie = &http.Cookie{
 			Name:   fmt.Sprintf("isso-%v", c.ID),
-			Path:   "/",
+			Path:   path,
 			MaxAge: -1,
 			Secure: true,
 		}
@@ -517,16 +573,16 @@ func (isso *ISSO) setcookie(c Comment, w http.ResponseWriter, delete bool) {
 		cookie := &http.Cookie{
 			Name:   fmt.Sprintf("%v", c.ID),
 			Value:  encoded,
-			Path:   "/",
-			MaxAge: isso.config.MaxAge,
+			Path:   path,
+			MaxAge: isso.config().MaxAge,
 			Secure: true,
 		}
 		http.SetCookie(w, cookie)
 		cookie = &http.Cookie{
 			Name:   fmt.Sprintf("isso-%v", c.ID),
 			Value:  encoded,
-			Path:   "/",
-			MaxAge: isso.config.MaxAge,
+			Path:   path,
+			MaxAge: isso.config().MaxAge,
 			Secure: true,
 		}
 		if v := cookie.String(); v != "" {
@@ -4,7 +4,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"net"
 	"net/http"
 	"net/url"
 	"strings"
@@ -21,37 +20,6 @@ func jsonBind(r io.ReadCloser, obj interface{}) error {
 	return nil
 }
 
-func findClientIP(r *http.Request) string {
-	headers := []string{"X-Forwarded-For", "X-Real-Ip"}
-	for _, header := range headers {
-		value := r.Header.Get(header)
-
-		if value != "" {
-			addresses := strings.Split(value, ",")
-			address := strings.TrimSpace(addresses[0])
-
-			if net.ParseIP(address) != nil {
-				return address
-			}
-		}
-	}
-
-	// Fallback to TCP/IP source IP address.
-	var remoteIP string
-	if strings.ContainsRune(r.RemoteAddr, ':') {
-		remoteIP, _, _ = net.SplitHostPort(r.RemoteAddr)
-	} else {
-		remoteIP = r.RemoteAddr
-	}
-
-	// When listening on a Unix socket, RemoteAddr is empty.
-	if remoteIP == "" {
-		remoteIP = "127.0.0.1"
-	}
-
-	return remoteIP
-}
-
 // FindOrigin first try to find origin header, then `referer`
 func FindOrigin(r *http.Request) string {
 	origin := r.Header.Get("origin")
@@ -67,3 +35,37 @@ func FindOrigin(r *http.Request) string {
 	}
 	return origin
 }
+
+// publicEndpoint return the configured public endpoint,
+// or guess it from request and site prefix when not set.
+func (isso *ISSO) publicEndpoint(r *http.Request) string {
+	if isso.config().Server.PublicEndpoint != "" {
+		return strings.TrimSuffix(isso.config().Server.PublicEndpoint, "/")
+	}
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s%s", scheme, r.Host, sitePrefixFromContext(r.Context()))
+}
+
+// cookiePath scope cookies to the site when the request is dispatched by
+// its prefix, so that websites served together do not overwrite cookies of
+// each other. Requests dispatched by Origin share the root path.
+func (isso *ISSO) cookiePath(r *http.Request) string {
+	prefix := sitePrefixFromContext(r.Context())
+	if prefix == "" {
+		return "/"
+	}
+	if u, err := url.ParseRequestURI(r.RequestURI); err != nil ||
+		(u.Path != prefix && !strings.HasPrefix(u.Path, prefix+"/")) {
+		return "/"
+	}
+	// the prefix may be behind another one added by reverse proxy.
+	if endpoint := isso.config().Server.PublicEndpoint; endpoint != "" {
+		if u, err := url.Parse(endpoint); err == nil && u.Path != "" {
+			return strings.TrimSuffix(u.Path, "/")
+		}
+	}
+	return prefix
+}
@@ -2,11 +2,16 @@ package isso
 
 import (
 	"context"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"sync/atomic"
 
 	"github.com/gorilla/securecookie"
 	"wrong.wang/x/go-isso/config"
 	"wrong.wang/x/go-isso/event"
 	"wrong.wang/x/go-isso/logger"
+	"wrong.wang/x/go-isso/tool/clientip"
 	"wrong.wang/x/go-isso/tool/hash"
 	"wrong.wang/x/go-isso/tool/markdown"
 )
@@ -30,49 +35,111 @@ func RequestIDFromContext(ctx context.Context) string {
 	return requestID
 }
 
+// sitePrefixContextKey carry the path prefix of the site when serving multiple websites.
+var sitePrefixContextKey issoContextKey = 2
+
+// WithSitePrefix return a copy of ctx with the path prefix the site is served under.
+func WithSitePrefix(ctx context.Context, prefix string) context.Context {
+	return context.WithValue(ctx, sitePrefixContextKey, prefix)
+}
+
+func sitePrefixFromContext(ctx context.Context) string {
+	prefix, _ := ctx.Value(sitePrefixContextKey).(string)
+	return prefix
+}
+
 // ISSO do the main logical staff
 type ISSO struct {
 	storage Storage
-	config  config.Config
+	live    atomic.Value // *live
 	tools   tools
 }
 
 type tools struct {
-	securecookie *securecookie.SecureCookie
+	securecookie keyring
 	hash         *hash.Worker
-	markdown     *markdown.Worker
 	event        *event.Bus
 }
 
+// live hold config and tools built from it, which are swapped together by Reload.
+type live struct {
+	config   config.Config
+	clientip *clientip.Resolver
+	markdown *markdown.Worker
+}
+
+func newLive(cfg config.Config) (*live, error) {
+	clientIP, err := clientip.New(cfg.Server.TrustedProxies)
+	if err != nil {
+		return nil, fmt.Errorf("init trusted proxies failed: %w", err)
+	}
+	return &live{
+		config:   cfg,
+		clientip: clientIP,
+		markdown: markdown.New(cfg.Server.Guard.Markup.AllowedElements, cfg.Server.Guard.Markup.AllowedAttributes),
+	}, nil
+}
+
+// Reload swap in cfg, requests being served keep the old one.
+// Settings used at startup, e.g. hash and max age of cookie keys, are not affected.
+func (isso *ISSO) Reload(cfg config.Config) error {
+	l, err := newLive(cfg)
+	if err != nil {
+		return err
+	}
+	isso.live.Store(l)
+	return nil
+}
+
+func (isso *ISSO) config() *config.Config {
+	return &isso.live.Load().(*live).config
+}
+
+func (isso *ISSO) markdown() *markdown.Worker {
+	return isso.live.Load().(*live).markdown
+}
+
+func (isso *ISSO) findClientIP(r *http.Request) string {
+	return isso.live.Load().(*live).clientip.ClientIP(r)
+}
+
+// RegisterNotifier let notifier subscribe events of ISSO.
+func (isso *ISSO) RegisterNotifier(n interface{ Register(*event.Bus) }) {
+	n.Register(isso.tools.event)
+}
+
 // New a ISSO instance
 func New(cfg config.Config, storage Storage) *ISSO {
-	var HashKey, BlockKey string
-	var err error
-	if HashKey, err = storage.GetPreference("hask-key"); err != nil {
-		HashKey = string(securecookie.GenerateRandomKey(64))
-		err := storage.SetPreference("hask-key", HashKey)
-		if err != nil {
-			logger.Fatal("set hash-key failed %w", err)
-		}
+	ctx := context.Background()
+	cookies, err := loadKeyring(ctx, storage, cfg.MaxAge)
+	if err != nil {
+		logger.Fatal("load cookie keys failed %v", err)
 	}
-	if BlockKey, err = storage.GetPreference("block-key"); err != nil {
-		BlockKey = string(securecookie.GenerateRandomKey(32))
-		err := storage.SetPreference("block-key", BlockKey)
-		if err != nil {
-			logger.Fatal("set block-key failed %w", err)
+
+	salt := cfg.Hash.Salt
+	if salt == "" {
+		// every deployment has its own salt.
+		if salt, err = storage.GetPreference(ctx, "hash-salt"); err != nil {
+			salt = hex.EncodeToString(securecookie.GenerateRandomKey(12))
+			if err := storage.SetPreference(ctx, "hash-salt", salt); err != nil {
+				logger.Fatal("set hash-salt failed %v", err)
+			}
 		}
 	}
-	BlockKey = string(securecookie.GenerateRandomKey(32))
-	HashKey = string(securecookie.GenerateRandomKey(64))
-	return &ISSO{
-		config: cfg,
+	hashWorker, err := hash.New(cfg.Hash.Algorithm, salt)
+	if err != nil {
+		logger.Fatal("init hash failed %v", err)
+	}
+	issoInstance := &ISSO{
 		tools: tools{
-			securecookie: securecookie.New([]byte(HashKey), []byte(BlockKey)),
-			// TODO: use conf to special hash
-			hash:     hash.New("pbkdf2:1000:6:sha1", "Eech7co8Ohloopo9Ol6baimi"),
-			markdown: markdown.New(),
-			event:    event.New(),
+			securecookie: cookies,
+			hash:         hashWorker,
+			event:        event.New(),
 		},
 		storage: storage,
 	}
+	if err := issoInstance.Reload(cfg); err != nil {
+		logger.Fatal("%v", err)
+	}
+	return issoInstance
 }
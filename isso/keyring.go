@@ -0,0 +1,179 @@
+package isso
+
+import (
+	"context"
+	"encoding/hex"
+	stdjson "encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gorilla/securecookie"
+)
+
+// preference keys of cookie signing keys, `hask-key` is kept for compatibility.
+// Keys are saved hex encoded, as preferences are text.
+const (
+	hashKeyName     = "hask-key"
+	blockKeyName    = "block-key"
+	retiredKeysName = "retired-keys"
+)
+
+// keyMaxAge is the default securecookie max age, moderation and unsubscribe
+// keys expire with it.
+const keyMaxAge = 86400 * 30
+
+// retiredKey is a key pair replaced by RotateKeys.
+type retiredKey struct {
+	HashKey  []byte `json:"hash_key"`
+	BlockKey []byte `json:"block_key"`
+	Retired  int64  `json:"retired"`
+}
+
+// keyring sign with the first codec, and verify with all of them.
+type keyring []securecookie.Codec
+
+func (k keyring) Encode(name string, value interface{}) (string, error) {
+	return securecookie.EncodeMulti(name, value, k...)
+}
+
+func (k keyring) Decode(name, value string, dst interface{}) error {
+	return securecookie.DecodeMulti(name, value, dst, k...)
+}
+
+// maxAge is how long a signed value is valid, at least keyMaxAge.
+func maxAge(cookieMaxAge int) int {
+	if cookieMaxAge > keyMaxAge {
+		return cookieMaxAge
+	}
+	return keyMaxAge
+}
+
+// loadKeyring load current key pair, generate it at first run, and retired
+// key pairs which may still verify values signed before rotation.
+func loadKeyring(ctx context.Context, storage PreferenceStorage, cookieMaxAge int) (keyring, error) {
+	hashKey, blockKey, err := currentKeys(ctx, storage)
+	if err != nil {
+		return nil, err
+	}
+	retired, err := retiredKeys(ctx, storage)
+	if err != nil {
+		return nil, err
+	}
+	age := maxAge(cookieMaxAge)
+	pairs := [][]byte{hashKey, blockKey}
+	for _, k := range unexpired(retired, age, time.Now()) {
+		pairs = append(pairs, k.HashKey, k.BlockKey)
+	}
+	codecs := securecookie.CodecsFromPairs(pairs...)
+	for _, c := range codecs {
+		c.(*securecookie.SecureCookie).MaxAge(age)
+	}
+	return keyring(codecs), nil
+}
+
+// RotateKeys generate a new cookie signing key pair, the old one is retired
+// and keeps verifying until all values signed with it expire.
+// purge drop all retired key pairs, which invalidate every signed value at once.
+// Running servers pick up new keys after restart.
+func RotateKeys(ctx context.Context, storage PreferenceStorage, cookieMaxAge int, purge bool) error {
+	hashKey, blockKey, err := currentKeys(ctx, storage)
+	if err != nil {
+		return err
+	}
+	retired := []retiredKey{}
+	if !purge {
+		if retired, err = retiredKeys(ctx, storage); err != nil {
+			return err
+		}
+		now := time.Now()
+		retired = append(unexpired(retired, maxAge(cookieMaxAge), now),
+			retiredKey{HashKey: hashKey, BlockKey: blockKey, Retired: now.Unix()})
+	}
+	data, err := stdjson.Marshal(retired)
+	if err != nil {
+		return err
+	}
+	// save retired keys first, so cookies still verify if the rotation is interrupted.
+	if err := putPreference(ctx, storage, retiredKeysName, string(data)); err != nil {
+		return fmt.Errorf("save retired keys failed: %w", err)
+	}
+	if err := storage.UpdatePreference(ctx, hashKeyName, hex.EncodeToString(securecookie.GenerateRandomKey(64))); err != nil {
+		return fmt.Errorf("save %s failed: %w", hashKeyName, err)
+	}
+	if err := storage.UpdatePreference(ctx, blockKeyName, hex.EncodeToString(securecookie.GenerateRandomKey(32))); err != nil {
+		return fmt.Errorf("save %s failed: %w", blockKeyName, err)
+	}
+	return nil
+}
+
+func currentKeys(ctx context.Context, storage PreferenceStorage) (hashKey, blockKey []byte, err error) {
+	if hashKey, err = getOrGenerateKey(ctx, storage, hashKeyName, 64); err != nil {
+		return nil, nil, err
+	}
+	if blockKey, err = getOrGenerateKey(ctx, storage, blockKeyName, 32); err != nil {
+		return nil, nil, err
+	}
+	return hashKey, blockKey, nil
+}
+
+func getOrGenerateKey(ctx context.Context, storage PreferenceStorage, name string, length int) ([]byte, error) {
+	value, err := storage.GetPreference(ctx, name)
+	if err == nil {
+		return decodeKey(value, length), nil
+	}
+	if !errors.Is(err, ErrStorageNotFound) {
+		return nil, fmt.Errorf("get %s failed: %w", name, err)
+	}
+	key := securecookie.GenerateRandomKey(length)
+	if err := storage.SetPreference(ctx, name, hex.EncodeToString(key)); err != nil {
+		return nil, fmt.Errorf("set %s failed: %w", name, err)
+	}
+	return key, nil
+}
+
+// decodeKey decode a hex encoded key of length bytes,
+// keys saved raw by older versions are used as they are.
+func decodeKey(value string, length int) []byte {
+	if len(value) == 2*length {
+		if key, err := hex.DecodeString(value); err == nil {
+			return key
+		}
+	}
+	return []byte(value)
+}
+
+func retiredKeys(ctx context.Context, storage PreferenceStorage) ([]retiredKey, error) {
+	data, err := storage.GetPreference(ctx, retiredKeysName)
+	if errors.Is(err, ErrStorageNotFound) {
+		return []retiredKey{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get retired keys failed: %w", err)
+	}
+	var keys []retiredKey
+	if err := stdjson.Unmarshal([]byte(data), &keys); err != nil {
+		return nil, fmt.Errorf("parse retired keys failed: %w", err)
+	}
+	return keys, nil
+}
+
+// unexpired drop key pairs retired more than age seconds ago,
+// nothing signed with them can pass max age check any more.
+func unexpired(keys []retiredKey, age int, now time.Time) []retiredKey {
+	kept := []retiredKey{}
+	for _, k := range keys {
+		if k.Retired+int64(age) > now.Unix() {
+			kept = append(kept, k)
+		}
+	}
+	return kept
+}
+
+func putPreference(ctx context.Context, storage PreferenceStorage, key, value string) error {
+	err := storage.UpdatePreference(ctx, key, value)
+	if errors.Is(err, ErrStorageNotFound) {
+		return storage.SetPreference(ctx, key, value)
+	}
+	return err
+}
@@ -0,0 +1,98 @@
+package isso
+
+import (
+	"context"
+	"encoding/hex"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/gorilla/securecookie"
+)
+
+// preferences is a PreferenceStorage in memory.
+type preferences map[string]string
+
+func (p preferences) GetPreference(ctx context.Context, key string) (string, error) {
+	if v, ok := p[key]; ok {
+		return v, nil
+	}
+	return "", ErrStorageNotFound
+}
+
+func (p preferences) SetPreference(ctx context.Context, key string, value string) error {
+	p[key] = value
+	return nil
+}
+
+func (p preferences) UpdatePreference(ctx context.Context, key string, value string) error {
+	if _, ok := p[key]; !ok {
+		return ErrStorageNotFound
+	}
+	p[key] = value
+	return nil
+}
+
+func (p preferences) FetchPreferences(ctx context.Context) (map[string]string, error) {
+	return p, nil
+}
+
+func Test_loadKeyring(t *testing.T) {
+	ctx := context.Background()
+	storage := preferences{}
+	keys, err := loadKeyring(ctx, storage, 900)
+	if err != nil {
+		t.Fatalf("loadKeyring() error = %v", err)
+	}
+	for name, length := range map[string]int{hashKeyName: 64, blockKeyName: 32} {
+		if v := storage[name]; len(v) != 2*length || !utf8.ValidString(v) {
+			t.Errorf("%s saved as %q, want %d bytes hex encoded", name, v, length)
+		}
+	}
+	signed, err := keys.Encode("test", int64(1))
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	if err := RotateKeys(ctx, storage, 900, false); err != nil {
+		t.Fatalf("RotateKeys() error = %v", err)
+	}
+	rotated, err := loadKeyring(ctx, storage, 900)
+	if err != nil {
+		t.Fatalf("loadKeyring() after rotation error = %v", err)
+	}
+	var id int64
+	if err := rotated.Decode("test", signed, &id); err != nil || id != 1 {
+		t.Errorf("value signed before rotation = %d, %v, want 1", id, err)
+	}
+}
+
+func Test_loadKeyring_rawKeys(t *testing.T) {
+	ctx := context.Background()
+	// keys saved raw by older versions.
+	hashKey, blockKey := securecookie.GenerateRandomKey(64), securecookie.GenerateRandomKey(32)
+	storage := preferences{hashKeyName: string(hashKey), blockKeyName: string(blockKey)}
+	keys, err := loadKeyring(ctx, storage, 900)
+	if err != nil {
+		t.Fatalf("loadKeyring() error = %v", err)
+	}
+	signed, err := securecookie.New(hashKey, blockKey).Encode("test", int64(1))
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	var id int64
+	if err := keys.Decode("test", signed, &id); err != nil || id != 1 {
+		t.Errorf("value signed by raw keys = %d, %v, want 1", id, err)
+	}
+}
+
+func Test_decodeKey(t *testing.T) {
+	key := []byte("0123456789abcdef")
+	if got := decodeKey(hex.EncodeToString(key), len(key)); string(got) != string(key) {
+		t.Errorf("decodeKey(hex) = %q, want %q", got, key)
+	}
+	// raw key of hex length is not hex by chance.
+	raw := "zz0123456789abcd"
+	if got := decodeKey(raw, 8); string(got) != raw {
+		t.Errorf("decodeKey(raw) = %q, want %q", got, raw)
+	}
+}
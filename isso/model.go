@@ -1,5 +1,10 @@
 package isso
 
+import (
+	"strings"
+	"time"
+)
+
 // Thread is comments thread
 type Thread struct {
 	ID    int64
@@ -10,6 +15,7 @@ type Thread struct {
 // Comment is comment saved in database
 type Comment struct {
 	ID           int64     `json:"id"`
+	TID          int64     `json:"-"`
 	Parent       *int64    `json:"parent"`
 	Created      float64   `json:"created"`
 	Modified     *float64  `json:"modified"`
@@ -31,9 +37,45 @@ type submittedComment struct {
 	Title string `json:"title" validate:"omitempty"`
 }
 
+// editInput is the editable part of a comment, used by both author and moderator.
+type editInput struct {
+	Text    string  `json:"text"  validate:"required,gte=3,lte=65535"`
+	Author  *string `json:"author"  validate:"omitempty,gte=1,lte=15"`
+	Email   *string `json:"email"  validate:"omitempty,email"`
+	Website *string `json:"website"  validate:"omitempty,url"`
+}
+
+// omitBlank treat blank author, email and website as unchanged,
+// the admin page sends empty fields as "".
+func (ei *editInput) omitBlank() {
+	for _, field := range []**string{&ei.Author, &ei.Email, &ei.Website} {
+		if *field != nil && strings.TrimSpace(**field) == "" {
+			*field = nil
+		}
+	}
+}
+
+// apply copy the input to comment and mark comment as modified now.
+func (ei editInput) apply(c Comment) Comment {
+	c.Text = ei.Text
+	if ei.Author != nil {
+		c.Author = *ei.Author
+	}
+	if ei.Email != nil {
+		c.Email = ei.Email
+	}
+	if ei.Website != nil {
+		c.Website = ei.Website
+	}
+	c.Modified = new(float64)
+	*c.Modified = float64(time.Now().UnixNano()) / float64(1e9)
+	return c
+}
+
 type reply struct {
 	Comment
 	Hash          string   `json:"hash"`
+	GravatarImage string   `json:"gravatar_image,omitempty"`
 	HiddenReplies *int64   `json:"hidden_replies,omitempty"`
 	TotalReplies  *int64   `json:"total_replies,omitempty"`
 	Replies       *[]reply `json:"replies,omitempty"`
@@ -58,12 +100,12 @@ func (c Comment) convert(plain bool, hash interface{ Hash(string) string }, mark
 
 	// markdowify
 	if plain {
-		return reply{c, hashresult, nil, nil, nil}, nil
+		return reply{Comment: c, Hash: hashresult}, nil
 	}
 	text, err := markdown.Convert(c.Text)
 	if err != nil {
-		return reply{c, hashresult, nil, nil, nil}, err
+		return reply{Comment: c, Hash: hashresult}, err
 	}
 	c.Text = text
-	return reply{c, hashresult, nil, nil, nil}, nil
+	return reply{Comment: c, Hash: hashresult}, nil
 }
@@ -0,0 +1,210 @@
+package isso
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"wrong.wang/x/go-isso/logger"
+	"wrong.wang/x/go-isso/response/json"
+	"wrong.wang/x/go-isso/tool/validator"
+)
+
+const descRequestInvalidKey = "invalid or expired key"
+
+// moderationKeyName is the securecookie name used to sign moderation keys,
+// keep it different from comment cookies so the two can not be swapped.
+// keys expire with the keyring max age (30 days, or max-age if longer).
+const moderationKeyName = "moderate"
+
+// unsubscribeKeyName is the securecookie name used to sign unsubscribe keys.
+const unsubscribeKeyName = "unsubscribe"
+
+var moderateConfirmPage = template.Must(template.New("moderate").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<script>
+	if (confirm({{.Action}} + ": Are you sure?")) {
+		var xhr = new XMLHttpRequest();
+		xhr.open("POST", window.location.href);
+		xhr.send(null);
+		xhr.onload = function() {
+			window.location.href = {{.Link}};
+		};
+	}
+</script>
+</head>
+</html>
+`))
+
+var unsubscribePage = template.Must(template.New("unsubscribe").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Successfully unsubscribed</title>
+</head>
+<body>
+<h1>Successfully unsubscribed</h1>
+<p>Email address {{.Email}} will no longer receive notifications for replies to comment {{.ID}}.</p>
+</body>
+</html>
+`))
+
+// ModerationKey return a signed key which allows to moderate comment `id`.
+func (isso *ISSO) ModerationKey(id int64) (string, error) {
+	return isso.tools.securecookie.Encode(moderationKeyName, id)
+}
+
+// ModerationURL return the link to do `action` on comment `id`,
+// action can be activate or delete. Edit link only accept POST with JSON body.
+func (isso *ISSO) ModerationURL(endpoint string, id int64, action string) (string, error) {
+	key, err := isso.ModerationKey(id)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s/id/%d/%s/%s", endpoint, id, action, key), nil
+}
+
+// UnsubscribeURL return the link for email to turn off reply notification of comment `id`.
+func (isso *ISSO) UnsubscribeURL(endpoint string, id int64, email string) (string, error) {
+	key, err := isso.tools.securecookie.Encode(unsubscribeKeyName, email)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s/id/%d/unsubscribe/%s/%s", endpoint, id, url.PathEscape(email), key), nil
+}
+
+// ModerateComment activate, edit or delete a comment with a signed moderation key.
+// GET ask moderator to confirm activate or delete, the action is done by POST.
+// Edit is only done by POST with JSON body, as the admin page does.
+func (isso *ISSO) ModerateComment() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		requestID := RequestIDFromContext(r.Context())
+		id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+		if err != nil {
+			json.BadRequest(requestID, w, err, descRequestInvalidParm)
+			return
+		}
+
+		var keyID int64
+		err = isso.tools.securecookie.Decode(moderationKeyName, mux.Vars(r)["key"], &keyID)
+		if err != nil || keyID != id {
+			json.Forbidden(requestID, w, err, descRequestInvalidKey)
+			return
+		}
+
+		comment, err := isso.storage.GetComment(r.Context(), id)
+		if err != nil {
+			if errors.Is(err, ErrStorageNotFound) {
+				json.NotFound(requestID, w, err, descStorageNotFound)
+				return
+			}
+			json.ServerError(requestID, w, err, descStorageUnhandledError)
+			return
+		}
+		thread, err := isso.storage.GetThreadByID(r.Context(), comment.TID)
+		if err != nil {
+			json.ServerError(requestID, w, err, descStorageUnhandledError)
+			return
+		}
+
+		action := mux.Vars(r)["action"]
+		if r.Method == http.MethodGet {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			_ = moderateConfirmPage.Execute(w, struct {
+				Action string
+				Link   string
+			}{action, isso.commentLink(thread, comment)})
+			return
+		}
+
+		switch action {
+		case "activate", "delete":
+			if err := isso.moderate(r.Context(), id, action); err != nil {
+				json.ServerError(requestID, w, err, descStorageUnhandledError)
+				return
+			}
+			json.OK(w, map[string]string{"message": fmt.Sprintf("comment %d %sd", id, action)})
+		case "edit":
+			var ei editInput
+			if err := jsonBind(r.Body, &ei); err != nil {
+				json.BadRequest(requestID, w, err, descRequestInvalidParm)
+				return
+			}
+			ei.omitBlank()
+			if err := validator.Validate(ei); err != nil {
+				json.BadRequest(requestID, w, err, fmt.Sprintf("edit post data validate failed: %s", err.Error()))
+				return
+			}
+			c, err := isso.storage.EditComment(r.Context(), ei.apply(comment))
+			if err != nil {
+				json.ServerError(requestID, w, err, descStorageUnhandledError)
+				return
+			}
+			isso.tools.event.Publish("comments.edit", logger.FromContext(r.Context()), c)
+			reply, _ := isso.convert(c, false)
+			json.OK(w, reply)
+		default:
+			json.BadRequest(requestID, w, nil, descRequestInvalidParm)
+		}
+	}
+}
+
+// UnsubscribeComment stop sending reply notification to email, the key is signed by UnsubscribeURL.
+func (isso *ISSO) UnsubscribeComment() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		requestID := RequestIDFromContext(r.Context())
+		id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+		if err != nil {
+			json.BadRequest(requestID, w, err, descRequestInvalidParm)
+			return
+		}
+		email := mux.Vars(r)["email"]
+
+		var keyEmail string
+		err = isso.tools.securecookie.Decode(unsubscribeKeyName, mux.Vars(r)["key"], &keyEmail)
+		if err != nil || keyEmail != email {
+			json.Forbidden(requestID, w, err, descRequestInvalidKey)
+			return
+		}
+
+		if _, err := isso.storage.GetComment(r.Context(), id); err != nil {
+			if errors.Is(err, ErrStorageNotFound) {
+				json.NotFound(requestID, w, err, descStorageNotFound)
+				return
+			}
+			json.ServerError(requestID, w, err, descStorageUnhandledError)
+			return
+		}
+		if err := isso.storage.UnsubscribeComment(r.Context(), email, id); err != nil {
+			json.ServerError(requestID, w, err, descStorageUnhandledError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_ = unsubscribePage.Execute(w, struct {
+			Email string
+			ID    int64
+		}{email, id})
+	}
+}
+
+// threadLink return the thread's page on the website.
+func (isso *ISSO) threadLink(thread Thread) string {
+	var host string
+	if len(isso.config().Host) > 0 {
+		host = isso.config().Host[0]
+	}
+	return strings.TrimSuffix(host, "/") + thread.URI
+}
+
+// commentLink return the comment's position on the website.
+func (isso *ISSO) commentLink(thread Thread, c Comment) string {
+	return fmt.Sprintf("%s#isso-%d", isso.threadLink(thread), c.ID)
+}
@@ -39,15 +39,28 @@ type Storage interface {
 	ThreadStorage
 	CommentStorage
 	PreferenceStorage
+	// NewCommentGuard return false, one of Guard reasons and description when c is rejected.
 	NewCommentGuard(ctx context.Context, c Comment, uri string,
-		ratelimit int, directreply int, replytoself bool, maxage int) (bool, string)
+		ratelimit int, directreply int, replytoself bool, maxage int) (bool, string, string)
 }
 
+// Reasons of guard rejections, which are stable to label metrics.
+const (
+	GuardRequireEmail  = "require-email"
+	GuardRequireAuthor = "require-author"
+	GuardRateLimit     = "ratelimit"
+	GuardDirectReply   = "direct-reply"
+	GuardReplyToSelf   = "reply-to-self"
+)
+
 // ThreadStorage handles all operations related to Thread and the database.
 type ThreadStorage interface {
 	GetThreadByURI(ctx context.Context, uri string) (Thread, error)
 	GetThreadByID(ctx context.Context, id int64) (Thread, error)
 	NewThread(ctx context.Context, uri string, title string) (Thread, error)
+	FetchThreads(ctx context.Context) ([]Thread, error)
+	// RestoreThread save thread with its ID, used to restore backup.
+	RestoreThread(ctx context.Context, t Thread) error
 }
 
 // CommentStorage handles all operations related to Comment and the database.
@@ -55,17 +68,30 @@ type CommentStorage interface {
 	IsApprovedAuthor(ctx context.Context, email string) bool
 	NewComment(ctx context.Context, c Comment, threadID int64, remoteAddr string) (Comment, error)
 	GetComment(ctx context.Context, id int64) (Comment, error)
+	// ImportComment save c into thread c.TID as it is, keep created time, votes and mode.
+	ImportComment(ctx context.Context, c Comment) (Comment, error)
+	// RestoreComment save c with its ID, used to restore backup.
+	RestoreComment(ctx context.Context, c Comment) error
 	// CountReply return parent-count map, 0 mean null `parent`
 	CountReply(ctx context.Context, uri string, mode int, after float64) (map[int64]int64, error)
 	FetchCommentsByURI(ctx context.Context, uri string, parent int64, mode int, orderBy string, asc bool) (map[int64][]Comment, error)
 	CountComment(ctx context.Context, uris []string) (map[string]int64, error)
+	// FetchComments fetch comments of all threads, used by admin.
+	FetchComments(ctx context.Context, mode int, orderBy string, asc bool, limit int64, offset int64) ([]Comment, error)
+	// CountCommentsByMode return mode-count map of all comments.
+	CountCommentsByMode(ctx context.Context) (map[int]int64, error)
+	ActivateComment(ctx context.Context, id int64) error
 	EditComment(ctx context.Context, c Comment) (Comment, error)
+	// UnsubscribeComment turn off reply notification of email's comments for comment `id` and its replies.
+	UnsubscribeComment(ctx context.Context, email string, id int64) error
 	DeleteComment(ctx context.Context, cid int64) (Comment, error)
 	VoteComment(ctx context.Context, c Comment, up bool) error
 }
 
 // PreferenceStorage handles all operations related to Preference and the database.
 type PreferenceStorage interface {
-	GetPreference(key string) (string, error)
-	SetPreference(key string, value string) error
+	GetPreference(ctx context.Context, key string) (string, error)
+	SetPreference(ctx context.Context, key string, value string) error
+	UpdatePreference(ctx context.Context, key string, value string) error
+	FetchPreferences(ctx context.Context) (map[string]string, error)
 }
@@ -35,9 +35,9 @@ function edit(com_id, hash, author, email, website, comment, isso_host_script) {
   ajax({method: "POST",
         url: isso_host_script + "/id/" + com_id + "/edit/" + hash,
         data: JSON.stringify({text: comment,
-                              author: author,
-                              email: email,
-                              website: website}),
+                              author: author || null,
+                              email: email || null,
+                              website: website || null}),
         success: function(ret){
           console.log("edit successed: ", ret);// TODO display some pretty stuff & update msg
         },
@@ -7,11 +7,15 @@
 package logger
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"runtime"
 	"strings"
+	"sync"
 	"time"
 
 	"wrong.wang/x/go-isso/version"
@@ -20,12 +24,16 @@ import (
 var requestedLevel = InfoLevel
 var displayDateTime = false
 var displayRuntime = false
+var displayJSON = false
 
 // LogLevel type.
 type LogLevel uint32
 
 var outputWriter io.Writer = os.Stderr
 
+// outputMutex keep lines from being interleaved.
+var outputMutex sync.Mutex
+
 const (
 	// FatalLevel should be used in fatal situations, the app will exit.
 	FatalLevel LogLevel = iota
@@ -65,42 +73,110 @@ func SetRuntime(ok bool) {
 	displayRuntime = ok
 }
 
+// EnableJSON write every log message as a JSON object in one line,
+// with time, level, message, caller and fields.
+func EnableJSON() {
+	displayJSON = true
+}
+
 // EnableDebug increases logging, more verbose (debug)
 func EnableDebug() {
 	requestedLevel = DebugLevel
 	SetRuntime(true)
-	formatMessage(InfoLevel, "Debug mode enabled")
+	formatMessage(nil, InfoLevel, "Debug mode enabled")
 }
 
 // Debug sends a debug log message.
 func Debug(format string, v ...interface{}) {
 	if requestedLevel >= DebugLevel {
-		formatMessage(DebugLevel, format, v...)
+		formatMessage(nil, DebugLevel, format, v...)
 	}
 }
 
 // Info sends an info log message.
 func Info(format string, v ...interface{}) {
 	if requestedLevel >= InfoLevel {
-		formatMessage(InfoLevel, format, v...)
+		formatMessage(nil, InfoLevel, format, v...)
 	}
 }
 
 // Error sends an error log message.
 func Error(format string, v ...interface{}) {
 	if requestedLevel >= ErrorLevel {
-		formatMessage(ErrorLevel, format, v...)
+		formatMessage(nil, ErrorLevel, format, v...)
 	}
 }
 
 // Fatal sends a fatal log message and stop the execution of the program.
 func Fatal(format string, v ...interface{}) {
 	if requestedLevel >= FatalLevel {
-		formatMessage(FatalLevel, format, v...)
+		formatMessage(nil, FatalLevel, format, v...)
 		os.Exit(1)
 	}
 }
 
+type field struct {
+	key   string
+	value interface{}
+}
+
+// Entry sends log messages with fields, e.g. request ID.
+type Entry struct {
+	fields []field
+}
+
+// With return an Entry with the field.
+func With(key string, value interface{}) *Entry {
+	return (*Entry)(nil).With(key, value)
+}
+
+// With return a copy of e with the field added.
+func (e *Entry) With(key string, value interface{}) *Entry {
+	n := &Entry{}
+	if e != nil {
+		n.fields = append(n.fields, e.fields...)
+	}
+	n.fields = append(n.fields, field{key, value})
+	return n
+}
+
+// Debug sends a debug log message with fields of e.
+func (e *Entry) Debug(format string, v ...interface{}) {
+	if requestedLevel >= DebugLevel {
+		formatMessage(e, DebugLevel, format, v...)
+	}
+}
+
+// Info sends an info log message with fields of e.
+func (e *Entry) Info(format string, v ...interface{}) {
+	if requestedLevel >= InfoLevel {
+		formatMessage(e, InfoLevel, format, v...)
+	}
+}
+
+// Error sends an error log message with fields of e.
+func (e *Entry) Error(format string, v ...interface{}) {
+	if requestedLevel >= ErrorLevel {
+		formatMessage(e, ErrorLevel, format, v...)
+	}
+}
+
+type entryContextKey struct{}
+
+// NewContext return a copy of ctx carrying e,
+// messages sent by the Entry from this context have fields of e.
+func NewContext(ctx context.Context, e *Entry) context.Context {
+	return context.WithValue(ctx, entryContextKey{}, e)
+}
+
+// FromContext return the Entry carried by ctx, or an Entry without fields.
+func FromContext(ctx context.Context) *Entry {
+	if e, ok := ctx.Value(entryContextKey{}).(*Entry); ok {
+		return e
+	}
+	return &Entry{}
+}
+
 // SetOutput sets the output destination for the logger.
 func SetOutput(w io.Writer) {
 	outputWriter = w
@@ -111,26 +187,80 @@ func funcname(name string) string {
 	return strings.TrimPrefix(name, version.Mod)
 }
 
-func formatMessage(level LogLevel, format string, v ...interface{}) {
-	var prefix string
+func formatMessage(e *Entry, level LogLevel, format string, v ...interface{}) {
 	var caller string
-
-	if displayDateTime {
-		prefix = fmt.Sprintf("[%s]", time.Now().Format("2006-01-02T15:04:05"))
-	}
-
-	prefix += fmt.Sprintf(" [%s]", level)
-
-	if displayRuntime {
+	if displayRuntime || displayJSON {
 		pc, _, _, ok := runtime.Caller(2)
 		if !ok {
 			caller = "unkown"
 		} else {
 			fn := runtime.FuncForPC(pc)
-			caller = fn.Name()
+			caller = funcname(fn.Name())
 		}
-		prefix += fmt.Sprintf(" %s - ", funcname(caller))
 	}
+	var fields []field
+	if e != nil {
+		fields = e.fields
+	}
+	message := fmt.Sprintf(format, v...)
+
+	var line string
+	if displayJSON {
+		line = jsonLine(level, caller, message, fields)
+	} else {
+		line = textLine(level, caller, message, fields)
+	}
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+	io.WriteString(outputWriter, line)
+}
+
+func textLine(level LogLevel, caller, message string, fields []field) string {
+	var b strings.Builder
+	if displayDateTime {
+		fmt.Fprintf(&b, "[%s]", time.Now().Format("2006-01-02T15:04:05"))
+	}
+	fmt.Fprintf(&b, " [%s]", level)
+	if displayRuntime {
+		fmt.Fprintf(&b, " %s - ", caller)
+	}
+	b.WriteString(message)
+	for _, f := range fields {
+		fmt.Fprintf(&b, " %s=%q", f.key, fmt.Sprint(f.value))
+	}
+	b.WriteString("\n")
+	return b.String()
+}
 
-	fmt.Fprintf(outputWriter, prefix+format+"\n", v...)
+func jsonLine(level LogLevel, caller, message string, fields []field) string {
+	var b bytes.Buffer
+	b.WriteString(`{"time":`)
+	writeJSONValue(&b, time.Now().UTC().Format(time.RFC3339Nano))
+	b.WriteString(`,"level":`)
+	writeJSONValue(&b, strings.ToLower(level.String()))
+	b.WriteString(`,"msg":`)
+	writeJSONValue(&b, message)
+	if caller != "" {
+		b.WriteString(`,"caller":`)
+		writeJSONValue(&b, caller)
+	}
+	for _, f := range fields {
+		b.WriteString(",")
+		writeJSONValue(&b, f.key)
+		b.WriteString(":")
+		writeJSONValue(&b, f.value)
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+func writeJSONValue(b *bytes.Buffer, v interface{}) {
+	if err, ok := v.(error); ok {
+		v = err.Error()
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		data, _ = json.Marshal(fmt.Sprint(v))
+	}
+	b.Write(data)
 }
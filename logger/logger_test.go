@@ -0,0 +1,85 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// capture write log messages of fn to a buffer, and restore the global settings afterwards.
+func capture(t *testing.T, jsonFormat bool, fn func()) string {
+	t.Helper()
+	level, withRuntime, withJSON, w := requestedLevel, displayRuntime, displayJSON, outputWriter
+	defer func() {
+		requestedLevel, displayRuntime, displayJSON = level, withRuntime, withJSON
+		SetOutput(w)
+	}()
+	var buf bytes.Buffer
+	SetOutput(&buf)
+	requestedLevel = DebugLevel
+	displayRuntime = false
+	displayJSON = jsonFormat
+	fn()
+	return buf.String()
+}
+
+func TestEntry_json(t *testing.T) {
+	out := capture(t, true, func() {
+		e := FromContext(NewContext(context.Background(), With("request_id", "42")))
+		e.With("error", errors.New(`bad "input"`)).Error("line\n<%s>", "a&b")
+	})
+	if strings.Count(out, "\n") != 1 || !strings.HasSuffix(out, "}\n") {
+		t.Fatalf("message should be one line of JSON, got %q", out)
+	}
+
+	var line map[string]string
+	if err := json.Unmarshal([]byte(out), &line); err != nil {
+		t.Fatalf("message is not JSON: %v, %q", err, out)
+	}
+	if _, err := time.Parse(time.RFC3339Nano, line["time"]); err != nil {
+		t.Errorf("time = %q, want RFC3339: %v", line["time"], err)
+	}
+	want := map[string]string{
+		"level":      "error",
+		"msg":        "line\n<a&b>",
+		"caller":     "logger.TestEntry_json.func1",
+		"request_id": "42",
+		"error":      `bad "input"`,
+	}
+	for k, v := range want {
+		if line[k] != v {
+			t.Errorf("%s = %q, want %q", k, line[k], v)
+		}
+	}
+	if len(line) != len(want)+1 {
+		t.Errorf("message has keys %v", line)
+	}
+	// keys keep the order, fields after time, level, msg and caller.
+	if !strings.HasPrefix(out, `{"time":`) || !strings.Contains(out, `,"request_id":"42","error":`) {
+		t.Errorf("wrong key order %q", out)
+	}
+}
+
+func TestEntry_text(t *testing.T) {
+	out := capture(t, false, func() {
+		With("request_id", "42").Info("hello %d", 1)
+	})
+	if want := " [INFO]hello 1 request_id=\"42\"\n"; out != want {
+		t.Errorf("text message = %q, want %q", out, want)
+	}
+}
+
+func TestEntry_level(t *testing.T) {
+	out := capture(t, true, func() {
+		requestedLevel = InfoLevel
+		With("request_id", "42").Debug("hidden")
+		FromContext(context.Background()).Info("shown")
+	})
+	if strings.Contains(out, "hidden") || !strings.Contains(out, `"msg":"shown"`) || strings.Contains(out, "request_id") {
+		t.Errorf("wrong messages %q", out)
+	}
+}
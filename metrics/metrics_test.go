@@ -0,0 +1,91 @@
+package metrics
+
+import (
+	"bytes"
+	"math"
+	"testing"
+)
+
+func TestRegistry_Write(t *testing.T) {
+	r := NewRegistry()
+	c := r.NewCounterVec("test_requests_total", "Requests.\nCounted.", "route", "code")
+	h := r.NewHistogramVec("test_duration_seconds", "Duration.", []float64{0.1, 1}, "route")
+	r.NewGaugeFunc("test_comments", "Comments.", func() []Sample {
+		return []Sample{{[]string{"b"}, 2}, {[]string{"a"}, 1.5}}
+	}, "mode")
+
+	c.Inc("fetch", "200")
+	c.Inc("fetch", "200")
+	c.Add(3, `new"\`+"\n", "201")
+	h.Observe(0.05, "fetch")
+	h.Observe(0.1, "fetch")
+	h.Observe(2, "fetch")
+
+	want := `# HELP test_comments Comments.
+# TYPE test_comments gauge
+test_comments{mode="a"} 1.5
+test_comments{mode="b"} 2
+# HELP test_duration_seconds Duration.
+# TYPE test_duration_seconds histogram
+test_duration_seconds_bucket{route="fetch",le="0.1"} 2
+test_duration_seconds_bucket{route="fetch",le="1"} 2
+test_duration_seconds_bucket{route="fetch",le="+Inf"} 3
+test_duration_seconds_sum{route="fetch"} 2.15
+test_duration_seconds_count{route="fetch"} 3
+# HELP test_requests_total Requests.\nCounted.
+# TYPE test_requests_total counter
+test_requests_total{route="fetch",code="200"} 2
+test_requests_total{route="new\"\\\n",code="201"} 3
+`
+	var b bytes.Buffer
+	r.Write(&b)
+	if got := b.String(); got != want {
+		t.Errorf("Registry.Write() got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestCounterVec_labelValues(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("CounterVec.Inc() with wrong amount of label values should panic")
+		}
+	}()
+	NewRegistry().NewCounterVec("test_total", "Test.", "a").Inc()
+}
+
+func TestRegistry_Write_noLabels(t *testing.T) {
+	r := NewRegistry()
+	c := r.NewCounterVec("test_total", `Path C:\tmp.`)
+	h := r.NewHistogramVec("test_seconds", "Empty.", []float64{1})
+	r.NewGaugeFunc("test_gauge", "Special values.", func() []Sample {
+		return []Sample{{nil, math.Inf(-1)}}
+	})
+	r.NewGaugeFunc("test_nan", "Not a number.", func() []Sample {
+		return []Sample{{nil, math.NaN()}}
+	})
+	c.Add(1e21)
+	h.Observe(math.Inf(1))
+
+	// metric without labels has no braces, every metric has HELP and TYPE.
+	want := `# HELP test_gauge Special values.
+# TYPE test_gauge gauge
+test_gauge -Inf
+# HELP test_nan Not a number.
+# TYPE test_nan gauge
+test_nan NaN
+# HELP test_seconds Empty.
+# TYPE test_seconds histogram
+test_seconds_bucket{le="1"} 0
+test_seconds_bucket{le="+Inf"} 1
+test_seconds_sum +Inf
+test_seconds_count 1
+# HELP test_total Path C:\\tmp.
+# TYPE test_total counter
+test_total 1e+21
+`
+	var b bytes.Buffer
+	r.Write(&b)
+	if got := b.String(); got != want {
+		t.Errorf("Registry.Write() got\n%s\nwant\n%s", got, want)
+	}
+}
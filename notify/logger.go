@@ -1,8 +1,6 @@
 package notify
 
 import (
-	"fmt"
-
 	"github.com/kr/pretty"
 	"wrong.wang/x/go-isso/event"
 	"wrong.wang/x/go-isso/isso"
@@ -21,22 +19,22 @@ func (l *Logger) Register(eb *event.Bus) {
 	eb.Subscribe("comments.activate", l.activateComment)
 }
 
-func (l *Logger) newThread(mt isso.Thread) {
-	logger.Info("new thread %s: %s", mt.ID, mt.Title)
+func (l *Logger) newThread(entry *logger.Entry, mt isso.Thread) {
+	entry.Info("new thread %d: %s", mt.ID, mt.Title)
 }
 
-func (l *Logger) newComment(c isso.Comment) {
-	logger.Info(fmt.Sprintf("create comment %# v", pretty.Formatter(c)))
+func (l *Logger) newComment(entry *logger.Entry, mt isso.Thread, c isso.Comment) {
+	entry.Info("create comment at %s %# v", mt.URI, pretty.Formatter(c))
 }
 
-func (l *Logger) editComment(id int) {
-	logger.Info("comment edited %d: ", id)
+func (l *Logger) editComment(entry *logger.Entry, c isso.Comment) {
+	entry.Info("comment edited %d: ", c.ID)
 }
 
-func (l *Logger) deleteComment(id int) {
-	logger.Info("comment deleted %d: ", id)
+func (l *Logger) deleteComment(entry *logger.Entry, id int64) {
+	entry.Info("comment deleted %d: ", id)
 }
 
-func (l *Logger) activateComment(id int) {
-	logger.Info("comment %d activated: ", id)
+func (l *Logger) activateComment(entry *logger.Entry, mt isso.Thread, c isso.Comment) {
+	entry.Info("comment %d activated: ", c.ID)
 }
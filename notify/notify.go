@@ -3,6 +3,9 @@ package notify
 import "wrong.wang/x/go-isso/event"
 
 // Notifier register handlers to *event.Bus
+// The first argument of comments.new:new-thread, comments.new:finish, comments.edit,
+// comments.delete and comments.activate is the *logger.Entry of the request which
+// published the event, so messages logged by handlers keep its request ID.
 type Notifier interface {
 	Register(*event.Bus)
 }
@@ -0,0 +1,279 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"wrong.wang/x/go-isso/config"
+	"wrong.wang/x/go-isso/event"
+	"wrong.wang/x/go-isso/isso"
+	"wrong.wang/x/go-isso/logger"
+)
+
+// Moderator can sign links to moderate comments and to unsubscribe reply notifications.
+type Moderator interface {
+	ModerationURL(endpoint string, id int64, action string) (string, error)
+	UnsubscribeURL(endpoint string, id int64, email string) (string, error)
+}
+
+// SMTP send notifications by email
+type SMTP struct {
+	settings  atomic.Value // smtpSettings
+	storage   isso.CommentStorage
+	moderator Moderator
+}
+
+// smtpSettings is taken from config, and can be swapped by Reload.
+type smtpSettings struct {
+	conf               config.SMTP
+	endpoint           string
+	host               string
+	replyNotifications bool
+}
+
+// NewSMTP return a SMTP notifier.
+// storage is used to find the parent comment when notify replies.
+func NewSMTP(cfg config.Config, storage isso.CommentStorage, moderator Moderator) *SMTP {
+	s := &SMTP{
+		storage:   storage,
+		moderator: moderator,
+	}
+	s.Reload(cfg)
+	return s
+}
+
+// Reload swap in smtp settings of cfg, mails being sent keep the old ones.
+func (s *SMTP) Reload(cfg config.Config) {
+	if cfg.Server.PublicEndpoint == "" {
+		logger.Error("smtp notification need `public-endpoint` to build moderation links")
+	}
+	var host string
+	if len(cfg.Host) > 0 {
+		host = strings.TrimSuffix(cfg.Host[0], "/")
+	}
+	s.settings.Store(smtpSettings{
+		conf:               cfg.SMTP,
+		endpoint:           strings.TrimSuffix(cfg.Server.PublicEndpoint, "/"),
+		host:               host,
+		replyNotifications: cfg.ReplyNotifications,
+	})
+}
+
+func (s *SMTP) load() smtpSettings {
+	return s.settings.Load().(smtpSettings)
+}
+
+// Register Subscribe events
+func (s *SMTP) Register(eb *event.Bus) {
+	eb.Subscribe("comments.new:finish", s.newComment)
+	eb.Subscribe("comments.activate", s.activateComment)
+}
+
+func (s *SMTP) newComment(entry *logger.Entry, mt isso.Thread, c isso.Comment) {
+	st := s.load()
+	body, err := s.formatAdmin(st, mt, c)
+	if err != nil {
+		entry.Error("smtp: format notification for comment %d failed: %v", c.ID, err)
+		return
+	}
+	if err := send(st.conf, st.conf.To, mt.Title, body); err != nil {
+		entry.Error("smtp: notify new comment %d failed: %v", c.ID, err)
+	}
+	if c.Mode == isso.ModeAccepted {
+		s.notifyParent(entry, st, mt, c)
+	}
+}
+
+// activateComment notify the parent's author once a moderated reply is published.
+func (s *SMTP) activateComment(entry *logger.Entry, mt isso.Thread, c isso.Comment) {
+	s.notifyParent(entry, s.load(), mt, c)
+}
+
+func (s *SMTP) notifyParent(entry *logger.Entry, st smtpSettings, mt isso.Thread, c isso.Comment) {
+	if !st.replyNotifications || c.Parent == nil {
+		return
+	}
+	parent, err := s.storage.GetComment(logger.NewContext(context.Background(), entry), *c.Parent)
+	if err != nil {
+		entry.Error("smtp: get parent of comment %d failed: %v", c.ID, err)
+		return
+	}
+	if parent.Notification == 0 || parent.Email == nil || *parent.Email == "" {
+		return
+	}
+	if c.Email != nil && *c.Email == *parent.Email {
+		// do not notify people who reply to themselves.
+		return
+	}
+	body, err := s.formatReply(st, mt, c, parent)
+	if err != nil {
+		entry.Error("smtp: format reply notification for comment %d failed: %v", c.ID, err)
+		return
+	}
+	if err := send(st.conf, *parent.Email, "Re: New comment posted on "+mt.Title, body); err != nil {
+		entry.Error("smtp: notify reply %d to comment %d failed: %v", c.ID, parent.ID, err)
+	}
+}
+
+func (s *SMTP) formatAdmin(st smtpSettings, mt isso.Thread, c isso.Comment) (string, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s wrote:\n\n%s\n\n", author(c), c.Text)
+	if c.Website != nil && *c.Website != "" {
+		fmt.Fprintf(&b, "User's URL: %s\n", *c.Website)
+	}
+	if c.Email != nil && *c.Email != "" {
+		fmt.Fprintf(&b, "User's email: %s\n", *c.Email)
+	}
+	fmt.Fprintf(&b, "IP address: %s\n", c.RemoteAddr)
+	fmt.Fprintf(&b, "Link to comment: %s%s#isso-%d\n\n---\n", st.host, mt.URI, c.ID)
+
+	deleteURL, err := s.moderator.ModerationURL(st.endpoint, c.ID, "delete")
+	if err != nil {
+		return "", err
+	}
+	fmt.Fprintf(&b, "Delete comment: %s\n", deleteURL)
+	if c.Mode == isso.ModeModeration {
+		activateURL, err := s.moderator.ModerationURL(st.endpoint, c.ID, "activate")
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&b, "Activate comment: %s\n", activateURL)
+	}
+	return b.String(), nil
+}
+
+func (s *SMTP) formatReply(st smtpSettings, mt isso.Thread, c isso.Comment, parent isso.Comment) (string, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s replied to your comment on %s:\n\n%s\n\n", author(c), mt.Title, c.Text)
+	fmt.Fprintf(&b, "Link to comment: %s%s#isso-%d\n\n---\n", st.host, mt.URI, c.ID)
+
+	unsubscribeURL, err := s.moderator.UnsubscribeURL(st.endpoint, parent.ID, *parent.Email)
+	if err != nil {
+		return "", err
+	}
+	fmt.Fprintf(&b, "Unsubscribe from this conversation: %s\n", unsubscribeURL)
+	return b.String(), nil
+}
+
+func author(c isso.Comment) string {
+	if c.Author == "" {
+		return "Anonymous"
+	}
+	return c.Author
+}
+
+// send deliver a plain text email to `to`.
+func send(conf config.SMTP, to, subject, body string) error {
+	if to == "" {
+		return fmt.Errorf("no recipient")
+	}
+	msg, err := message(conf, to, subject, body)
+	if err != nil {
+		return err
+	}
+
+	client, err := dial(conf)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if conf.Username != "" {
+		auth := smtp.PlainAuth("", conf.Username, conf.Password, conf.Host)
+		if err := client.Auth(auth); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(address(conf.From)); err != nil {
+		return err
+	}
+	if err := client.Rcpt(address(to)); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// dial connect to smtp server according to `security`: none, starttls or ssl.
+func dial(conf config.SMTP) (*smtp.Client, error) {
+	timeout := time.Duration(conf.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	addr := net.JoinHostPort(conf.Host, strconv.Itoa(conf.Port))
+	tlsConfig := &tls.Config{ServerName: conf.Host}
+
+	var conn net.Conn
+	var err error
+	if conf.Security == "ssl" {
+		conn, err = tls.DialWithDialer(&net.Dialer{Timeout: timeout}, "tcp", addr, tlsConfig)
+	} else {
+		conn, err = net.DialTimeout("tcp", addr, timeout)
+	}
+	if err != nil {
+		return nil, err
+	}
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	client, err := smtp.NewClient(conn, conf.Host)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if conf.Security == "starttls" {
+		if err := client.StartTLS(tlsConfig); err != nil {
+			client.Close()
+			return nil, err
+		}
+	}
+	return client, nil
+}
+
+func message(conf config.SMTP, to, subject, body string) ([]byte, error) {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", conf.From)
+	fmt.Fprintf(&b, "To: %s\r\n", to)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+
+	qp := quotedprintable.NewWriter(&b)
+	if _, err := qp.Write([]byte(strings.ReplaceAll(body, "\n", "\r\n"))); err != nil {
+		return nil, err
+	}
+	if err := qp.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// address extract `isso@example.tld` from `"Foo Bar" <isso@example.tld>`
+func address(s string) string {
+	a, err := mail.ParseAddress(s)
+	if err != nil {
+		return strings.TrimSpace(s)
+	}
+	return a.Address
+}
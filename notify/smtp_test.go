@@ -0,0 +1,227 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"mime/quotedprintable"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"wrong.wang/x/go-isso/config"
+	"wrong.wang/x/go-isso/isso"
+	"wrong.wang/x/go-isso/logger"
+)
+
+type fakeMail struct {
+	from string
+	to   []string
+	data string
+}
+
+// fakeSMTPServer accept mails without auth and tls, keep them in memory.
+type fakeSMTPServer struct {
+	sync.Mutex
+	listener net.Listener
+	mails    []fakeMail
+}
+
+func newFakeSMTPServer(t *testing.T) *fakeSMTPServer {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	s := &fakeSMTPServer{listener: l}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *fakeSMTPServer) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { fmt.Fprintf(conn, "%s\r\n", line) }
+	reply("220 fake ESMTP")
+
+	var m fakeMail
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimSpace(line)
+		cmd := strings.ToUpper(line)
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			reply("250 fake")
+		case strings.HasPrefix(cmd, "MAIL FROM:"):
+			m = fakeMail{from: strings.Trim(line[len("MAIL FROM:"):], "<>")}
+			reply("250 OK")
+		case strings.HasPrefix(cmd, "RCPT TO:"):
+			m.to = append(m.to, strings.Trim(line[len("RCPT TO:"):], "<>"))
+			reply("250 OK")
+		case cmd == "DATA":
+			reply("354 go ahead")
+			var data strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				data.WriteString(l)
+			}
+			m.data = data.String()
+			s.Lock()
+			s.mails = append(s.mails, m)
+			s.Unlock()
+			reply("250 OK")
+		case cmd == "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
+
+// body return the decoded body of a quoted-printable mail
+func (m fakeMail) body() string {
+	i := strings.Index(m.data, "\r\n\r\n")
+	if i < 0 {
+		return ""
+	}
+	b, _ := ioutil.ReadAll(quotedprintable.NewReader(strings.NewReader(m.data[i+4:])))
+	return string(b)
+}
+
+func (s *fakeSMTPServer) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+type fakeModerator struct{}
+
+func (fakeModerator) ModerationURL(endpoint string, id int64, action string) (string, error) {
+	return fmt.Sprintf("%s/id/%d/%s/key", endpoint, id, action), nil
+}
+
+func (fakeModerator) UnsubscribeURL(endpoint string, id int64, email string) (string, error) {
+	return fmt.Sprintf("%s/id/%d/unsubscribe/%s/key", endpoint, id, email), nil
+}
+
+type fakeCommentStorage struct {
+	isso.CommentStorage
+	comments map[int64]isso.Comment
+}
+
+func (f fakeCommentStorage) GetComment(ctx context.Context, id int64) (isso.Comment, error) {
+	c, ok := f.comments[id]
+	if !ok {
+		return isso.Comment{}, isso.ErrStorageNotFound
+	}
+	return c, nil
+}
+
+func TestSMTP(t *testing.T) {
+	server := newFakeSMTPServer(t)
+	defer server.listener.Close()
+
+	var cfg config.Config
+	cfg.Host = []string{"http://example.com"}
+	cfg.ReplyNotifications = true
+	cfg.Server.PublicEndpoint = "http://isso.example.com"
+	cfg.SMTP = config.SMTP{
+		Host:     "127.0.0.1",
+		Port:     server.port(),
+		Security: "none",
+		To:       "admin@example.com",
+		From:     `"isso" <isso@example.com>`,
+		Timeout:  2,
+	}
+
+	parentEmail := "parent@example.com"
+	parentID := int64(1)
+	storage := fakeCommentStorage{comments: map[int64]isso.Comment{
+		1: {ID: 1, Author: "parent", Email: &parentEmail, Notification: 1},
+	}}
+	s := NewSMTP(cfg, storage, fakeModerator{})
+	thread := isso.Thread{ID: 1, URI: "/post", Title: "Post"}
+
+	t.Run("moderation", func(t *testing.T) {
+		s.newComment(logger.With("request_id", "test"), thread, isso.Comment{ID: 2, Author: "bob", Text: "hello", Mode: isso.ModeModeration, RemoteAddr: "127.0.0.1"})
+		server.Lock()
+		defer server.Unlock()
+		if len(server.mails) != 1 {
+			t.Fatalf("want 1 mail, got %d", len(server.mails))
+		}
+		m := server.mails[0]
+		if m.from != "isso@example.com" || len(m.to) != 1 || m.to[0] != "admin@example.com" {
+			t.Errorf("wrong envelope %v", m)
+		}
+		if !strings.Contains(m.body(), "http://isso.example.com/id/2/activate/key") {
+			t.Errorf("mail does not contain activate link:\n%s", m.data)
+		}
+	})
+	t.Run("reply", func(t *testing.T) {
+		server.Lock()
+		server.mails = nil
+		server.Unlock()
+		s.newComment(logger.With("request_id", "test"), thread, isso.Comment{ID: 3, Parent: &parentID, Author: "alice", Text: "reply", Mode: isso.ModeAccepted, RemoteAddr: "127.0.0.1"})
+		server.Lock()
+		defer server.Unlock()
+		if len(server.mails) != 2 {
+			t.Fatalf("want 2 mails, got %d", len(server.mails))
+		}
+		if m := server.mails[1]; len(m.to) != 1 || m.to[0] != parentEmail {
+			t.Errorf("reply notification sent to %v, want %s", m.to, parentEmail)
+		}
+		if !strings.Contains(server.mails[1].body(), "/id/1/unsubscribe/parent@example.com/key") {
+			t.Errorf("reply notification does not contain unsubscribe link:\n%s", server.mails[1].data)
+		}
+		if strings.Contains(server.mails[0].body(), "/activate/") {
+			t.Errorf("accepted comment should not have activate link:\n%s", server.mails[0].data)
+		}
+	})
+	t.Run("reply to self", func(t *testing.T) {
+		server.Lock()
+		server.mails = nil
+		server.Unlock()
+		s.newComment(logger.With("request_id", "test"), thread, isso.Comment{ID: 4, Parent: &parentID, Email: &parentEmail, Author: "parent", Text: "self", Mode: isso.ModeAccepted, RemoteAddr: "127.0.0.1"})
+		server.Lock()
+		defer server.Unlock()
+		if len(server.mails) != 1 {
+			t.Errorf("want only admin mail, got %d", len(server.mails))
+		}
+	})
+}
+
+func Test_address(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{`"Foo Bar" <isso@example.tld>`, "isso@example.tld"},
+		{"isso@example.tld", "isso@example.tld"},
+		{" isso ", "isso"},
+	}
+	for i, tt := range tests {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			if got := address(tt.in); got != tt.want {
+				t.Errorf("address() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
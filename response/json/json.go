@@ -2,7 +2,6 @@ package json
 
 import (
 	"encoding/json"
-	"fmt"
 	"net/http"
 	"runtime"
 	"strings"
@@ -30,11 +29,11 @@ func writeErrorJSON(w http.ResponseWriter, err error, requestID string, desc str
 		caller = strings.TrimPrefix(fn.Name(), version.Mod)
 	}
 
-	errstr := fmt.Sprintf("%s %s - %s", requestID, caller, desc)
+	entry := logger.With("request_id", requestID).With("handler", caller)
 	if err != nil {
-		errstr = fmt.Sprintf("%s\n\t%v", errstr, err)
+		entry = entry.With("error", err)
 	}
-	logger.Error("%s", errstr)
+	entry.Error("%s", desc)
 
 	reason := desc
 	if reason == "" {
@@ -23,28 +23,32 @@ func registerRoute(router *mux.Router, isso *isso.ISSO) {
 	router.HandleFunc("/id/{id:[0-9]+}", isso.DeleteComment()).Methods("DELETE").Name("delete")
 	router.HandleFunc("/id/{id:[0-9]+}/{vote:(?:like|dislike)}", isso.VoteComment()).Methods("POST").Name("vote")
 
-	router.HandleFunc("/id/{id:[0-9]+}/{action:(?:edit|activate|delete)}/{key}", workInProcess).
+	// edit need a JSON body, it is only posted by the admin page.
+	router.HandleFunc("/id/{id:[0-9]+}/{action:(?:activate|delete)}/{key}", isso.ModerateComment()).
 		Methods("GET").Name("moderate_get")
-	router.HandleFunc("/id/{id:[0-9]+}/{action:(?:edit|activate|delete)}>/{key}", workInProcess).
+	router.HandleFunc("/id/{id:[0-9]+}/{action:(?:edit|activate|delete)}/{key}", isso.ModerateComment()).
 		Methods("POST").Name("moderate_post")
-	router.HandleFunc("/id/{id:[0-9]+}/unsubscribe/{email}/{key}>", workInProcess).
+	router.HandleFunc("/id/{id:[0-9]+}/unsubscribe/{email}/{key}", isso.UnsubscribeComment()).
 		Methods("GET").Name("unsubscribe")
 
 	// functional
 	router.HandleFunc("/demo", workInProcess).Methods("GET").Name("demo")
 	router.HandleFunc("/preview", isso.PreviewText()).Methods("POST").Name("preview")
+	router.HandleFunc("/avatar/{hash:[0-9a-f]{1,128}}.svg", isso.Avatar()).Methods("GET").Name("avatar")
 
 	// amdin staff
-	router.HandleFunc("/admin", workInProcess).Methods("GET").Name("admin")
-	router.HandleFunc("/login", workInProcess).Methods("POST").Name("login")
+	router.HandleFunc("/admin", isso.AdminDashboard()).Methods("GET").Name("admin")
+	router.HandleFunc("/admin", isso.AdminBulkModerate()).Methods("POST").Name("admin_bulk")
+	router.HandleFunc("/login", isso.AdminLogin()).Methods("POST").Name("login")
 
 	// ping
 	router.HandleFunc("/ping", ping).Name("ping")
 
 	// total staff
-	router.HandleFunc("/latest", workInProcess).Methods("GET").Name("latest")
-	router.HandleFunc("/count", workInProcess).Methods("GET").Name("count")
+	router.HandleFunc("/latest", isso.LatestComments()).Methods("GET").Name("latest")
+	router.HandleFunc("/count", isso.CountComment()).Methods("GET").Name("count")
 	router.HandleFunc("/count", isso.CountComment()).Methods("POST").Name("counts")
+	router.HandleFunc("/feed", isso.Feed()).Methods("GET").Name("feed")
 
 	router.PathPrefix("/js").Handler(http.FileServer(AssetFile()))
 
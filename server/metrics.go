@@ -0,0 +1,117 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"wrong.wang/x/go-isso/event"
+	"wrong.wang/x/go-isso/isso"
+	"wrong.wang/x/go-isso/logger"
+	"wrong.wang/x/go-isso/metrics"
+)
+
+// serverMetrics is all metrics of go-isso, labeled by site name.
+type serverMetrics struct {
+	registry        *metrics.Registry
+	requests        *metrics.CounterVec
+	requestDuration *metrics.HistogramVec
+	storageDuration *metrics.HistogramVec
+	storageErrors   *metrics.CounterVec
+	guardRejections *metrics.CounterVec
+	eventPublishes  *metrics.CounterVec
+	sites           []*site
+}
+
+func newServerMetrics() *serverMetrics {
+	r := metrics.NewRegistry()
+	m := &serverMetrics{
+		registry: r,
+		requests: r.NewCounterVec("isso_http_requests_total",
+			"Total HTTP requests by route and status code.", "site", "route", "code"),
+		requestDuration: r.NewHistogramVec("isso_http_request_duration_seconds",
+			"HTTP request latency by route.", metrics.DefBuckets, "site", "route"),
+		storageDuration: r.NewHistogramVec("isso_storage_duration_seconds",
+			"Storage call latency by method.", metrics.DefBuckets, "site", "method"),
+		storageErrors: r.NewCounterVec("isso_storage_errors_total",
+			"Storage call errors by method, not found is not an error.", "site", "method"),
+		guardRejections: r.NewCounterVec("isso_guard_rejections_total",
+			"New comments rejected by guard, by reason.", "site", "reason"),
+		eventPublishes: r.NewCounterVec("isso_event_publishes_total",
+			"Events published by topic.", "site", "topic"),
+	}
+	r.NewGaugeFunc("isso_comments", "Comments by mode.", m.comments, "site", "mode")
+	return m
+}
+
+// middleware count and time matched routes of a site.
+func (m *serverMetrics) middleware(site string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route := "unnamed"
+			if current := mux.CurrentRoute(r); current != nil && current.GetName() != "" {
+				route = current.GetName()
+			}
+			sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+			start := time.Now()
+			next.ServeHTTP(sw, r)
+			m.requestDuration.Observe(time.Since(start).Seconds(), site, route)
+			m.requests.Inc(site, route, strconv.Itoa(sw.status))
+		})
+	}
+}
+
+// Register count events and guard rejections published by ISSO of a site.
+func (m *serverMetrics) observer(site string) interface{ Register(*event.Bus) } {
+	return busObserver(func(eb *event.Bus) {
+		eb.OnPublish(func(topic string) {
+			m.eventPublishes.Inc(site, topic)
+		})
+		eb.Subscribe("comments.new:rejected", func(reason string) {
+			m.guardRejections.Inc(site, reason)
+		})
+	})
+}
+
+type busObserver func(eb *event.Bus)
+
+func (o busObserver) Register(eb *event.Bus) {
+	o(eb)
+}
+
+func (m *serverMetrics) observeStorage(site, method string, start time.Time, err error) {
+	m.storageDuration.Observe(time.Since(start).Seconds(), site, method)
+	if err != nil && !errors.Is(err, isso.ErrStorageNotFound) {
+		m.storageErrors.Inc(site, method)
+	}
+}
+
+var modeNames = map[int]string{
+	isso.ModeAccepted:   "accepted",
+	isso.ModeModeration: "moderation",
+	isso.ModeDeleted:    "deleted",
+}
+
+// comments count comments of all sites by mode when scraped.
+func (m *serverMetrics) comments() []metrics.Sample {
+	var samples []metrics.Sample
+	for _, s := range m.sites {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		counts, err := s.storage.CountCommentsByMode(ctx)
+		cancel()
+		if err != nil {
+			logger.Error("website %q: count comments for metrics failed: %v", s.name, err)
+			continue
+		}
+		for mode, name := range modeNames {
+			samples = append(samples, metrics.Sample{
+				LabelValues: []string{s.name, name},
+				Value:       float64(counts[mode]),
+			})
+		}
+	}
+	return samples
+}
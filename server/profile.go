@@ -0,0 +1,239 @@
+package server
+
+import (
+	"crypto/subtle"
+	stdjson "encoding/json"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"wrong.wang/x/go-isso/config"
+	"wrong.wang/x/go-isso/logger"
+)
+
+// defaultProfileListen is used with `profile = on`.
+const defaultProfileListen = "http://127.0.0.1:6060"
+
+// defaultMetricsListen is used with `metrics = on`.
+const defaultMetricsListen = "http://127.0.0.1:6061"
+
+// ProcessStatistics is a summary of memory usage and goroutines.
+type ProcessStatistics struct {
+	SysKB        uint64 `json:"sys_kb"`
+	InUseKB      uint64 `json:"in_use_kb"`
+	HeapInUseKB  uint64 `json:"heap_in_use_kb"`
+	StackSysKB   uint64 `json:"stack_sys_kb"`
+	StackInUseKB uint64 `json:"stack_in_use_kb"`
+	Goroutines   int    `json:"goroutines"`
+	NumCPU       int    `json:"num_cpu"`
+}
+
+// ReadProcessStatistics read statistics of the running process.
+func ReadProcessStatistics() ProcessStatistics {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	return ProcessStatistics{
+		SysKB:        m.Sys / 1024,
+		InUseKB:      (m.Sys - m.HeapReleased) / 1024,
+		HeapInUseKB:  m.HeapInuse / 1024,
+		StackSysKB:   m.StackSys / 1024,
+		StackInUseKB: m.StackInuse / 1024,
+		Goroutines:   runtime.NumGoroutine(),
+		NumCPU:       runtime.NumCPU(),
+	}
+}
+
+// optionalListen return listen address of an option which may be off, on or
+// an address, on is defaultListen and off is empty.
+func optionalListen(option, defaultListen string) string {
+	switch p := strings.TrimSpace(option); strings.ToLower(p) {
+	case "", "off", "false", "no", "0":
+		return ""
+	case "on", "true", "yes", "1":
+		return defaultListen
+	default:
+		return p
+	}
+}
+
+// startProfile serve pprof and diagnostics on a separate listener,
+// protected by basic auth with the admin password.
+func (s *Server) startProfile(cfg config.Config, timings *routeTimings) {
+	listen := optionalListen(cfg.Server.Profile, defaultProfileListen)
+	if cfg.Admin.Password == "" {
+		logger.Fatal("profile needs [admin] password to protect it")
+	}
+
+	router := mux.NewRouter()
+	router.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	router.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	router.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	router.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	// index also serves named profiles, e.g. /debug/pprof/goroutine?debug=2 dumps all goroutines.
+	router.PathPrefix("/debug/pprof/").HandlerFunc(pprof.Index)
+	router.HandleFunc("/debug/stats", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, ReadProcessStatistics())
+	})
+	router.HandleFunc("/debug/routes", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, timings.summary())
+	})
+
+	server := &http.Server{
+		Handler:     basicAuth(cfg.Admin.Password, router),
+		ReadTimeout: 10 * time.Second,
+		// cpu profile and trace take 30 seconds by default.
+		WriteTimeout:   5 * time.Minute,
+		IdleTimeout:    20 * time.Second,
+		MaxHeaderBytes: 1 << 20,
+	}
+	s.startOptional(server, "profile", listen)
+}
+
+// startMetrics serve Prometheus metrics on a separate listener, protected by
+// basic auth when the admin password is set.
+func (s *Server) startMetrics(cfg config.Config, m *serverMetrics) {
+	listen := optionalListen(cfg.Server.Metrics, defaultMetricsListen)
+	var handler http.Handler = m.registry
+	if cfg.Admin.Password != "" {
+		handler = basicAuth(cfg.Admin.Password, handler)
+	}
+	router := http.NewServeMux()
+	router.Handle("/metrics", handler)
+
+	server := &http.Server{
+		Handler:        router,
+		ReadTimeout:    10 * time.Second,
+		WriteTimeout:   10 * time.Second,
+		IdleTimeout:    20 * time.Second,
+		MaxHeaderBytes: 1 << 20,
+	}
+	s.startOptional(server, "metrics", listen)
+}
+
+// startOptional start a listener of profile or metrics, which do not support TLS.
+func (s *Server) startOptional(server *http.Server, name, listen string) {
+	switch {
+	case strings.HasPrefix(listen, "unix://"):
+		startUnixSocketServer(server, strings.TrimPrefix(listen, "unix://"))
+	case strings.HasPrefix(listen, "http://"):
+		startHTTPServer(server, strings.TrimPrefix(listen, "http://"))
+	default:
+		logger.Fatal("not supported %s listen address: %s", name, listen)
+	}
+	s.servers = append(s.servers, server)
+}
+
+func basicAuth(password string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, p, ok := r.BasicAuth()
+		if !ok || subtle.ConstantTimeCompare([]byte(p), []byte(password)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Basic realm="go-isso profile"`)
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	encoder := stdjson.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(v)
+}
+
+// routeTimings collect how long requests of each route take.
+type routeTimings struct {
+	mu     sync.Mutex
+	since  time.Time
+	routes map[string]*routeTiming
+}
+
+type routeTiming struct {
+	count  int64
+	errors int64
+	total  time.Duration
+	max    time.Duration
+}
+
+// RouteSummary is timing of a route since start.
+type RouteSummary struct {
+	Count  int64   `json:"count"`
+	Errors int64   `json:"errors"`
+	MeanMS float64 `json:"mean_ms"`
+	MaxMS  float64 `json:"max_ms"`
+}
+
+func newRouteTimings() *routeTimings {
+	return &routeTimings{since: time.Now(), routes: map[string]*routeTiming{}}
+}
+
+// middleware time matched routes of a site, named as `site/route` when site is named.
+func (t *routeTimings) middleware(site string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			name := "unnamed"
+			if route := mux.CurrentRoute(r); route != nil && route.GetName() != "" {
+				name = route.GetName()
+			}
+			if site != "" {
+				name = site + "/" + name
+			}
+			sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+			start := time.Now()
+			next.ServeHTTP(sw, r)
+			t.observe(name, time.Since(start), sw.status)
+		})
+	}
+}
+
+func (t *routeTimings) observe(name string, d time.Duration, status int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	rt, ok := t.routes[name]
+	if !ok {
+		rt = &routeTiming{}
+		t.routes[name] = rt
+	}
+	rt.count++
+	if status >= http.StatusInternalServerError {
+		rt.errors++
+	}
+	rt.total += d
+	if d > rt.max {
+		rt.max = d
+	}
+}
+
+func (t *routeTimings) summary() map[string]interface{} {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	routes := map[string]RouteSummary{}
+	for name, rt := range t.routes {
+		routes[name] = RouteSummary{
+			Count:  rt.count,
+			Errors: rt.errors,
+			MeanMS: float64(rt.total) / float64(rt.count) / float64(time.Millisecond),
+			MaxMS:  float64(rt.max) / float64(time.Millisecond),
+		}
+	}
+	return map[string]interface{}{
+		"since":  t.since.UTC().Format(time.RFC3339),
+		"routes": routes,
+	}
+}
+
+// statusWriter remember status code written by handler.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
@@ -2,6 +2,7 @@ package server
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"net"
 	"net/http"
@@ -9,36 +10,129 @@ import (
 	"strings"
 	"time"
 
-	"github.com/gorilla/mux"
-	"github.com/rs/cors"
 	"github.com/sony/sonyflake"
 	"wrong.wang/x/go-isso/config"
-	"wrong.wang/x/go-isso/database"
 	"wrong.wang/x/go-isso/isso"
 	"wrong.wang/x/go-isso/logger"
+	"wrong.wang/x/go-isso/notify"
 )
 
-// Serve starts a new HTTP server.
-func Serve(cfg config.Config) *http.Server {
-	server := &http.Server{
-		Handler:        setupHandler(cfg),
-		ReadTimeout:    10 * time.Second,
-		WriteTimeout:   10 * time.Second,
-		IdleTimeout:    20 * time.Second,
-		MaxHeaderBytes: 1 << 20,
-	}
-	switch {
-	case strings.HasPrefix(cfg.Server.Listen, "unix://"):
-		startUnixSocketServer(server, strings.TrimPrefix(cfg.Server.Listen, "unix://"))
-	case strings.HasPrefix(cfg.Server.Listen, "http://"):
-		server.Addr = strings.TrimPrefix(cfg.Server.Listen, "http://")
-		startHTTPServer(server)
-	default:
-		logger.Fatal("not supported listen address:", cfg.Server.Listen)
+// Server is the HTTP server of go-isso, which may listen on several addresses.
+type Server struct {
+	servers     []*http.Server
+	sites       []*site
+	certificate *certificate
+}
+
+// Serve starts a new HTTP server for one or more websites,
+// listen addresses are taken from the first config.
+func Serve(cfgs []config.Config) *Server {
+	if err := checkSites(cfgs); err != nil {
+		logger.Fatal("invalid websites config: %v", err)
+	}
+	cfg := cfgs[0]
+	if len(cfg.Server.Listen) == 0 {
+		logger.Fatal("no listen address")
+	}
+	var timings *routeTimings
+	if optionalListen(cfg.Server.Profile, defaultProfileListen) != "" {
+		timings = newRouteTimings()
+	}
+	var m *serverMetrics
+	if optionalListen(cfg.Server.Metrics, defaultMetricsListen) != "" {
+		m = newServerMetrics()
+	}
+	server := &Server{}
+	for _, cfg := range cfgs {
+		server.sites = append(server.sites, newSite(cfg, timings, m))
+	}
+	if m != nil {
+		m.sites = server.sites
+	}
+	handler := setupHandler(server.sites)
+	for _, listen := range cfg.Server.Listen {
+		// every listener has its own http.Server, plain and TLS listeners
+		// sharing one http.Server breaks HTTP/2.
+		s := &http.Server{
+			Handler:        handler,
+			ReadTimeout:    10 * time.Second,
+			WriteTimeout:   10 * time.Second,
+			IdleTimeout:    20 * time.Second,
+			MaxHeaderBytes: 1 << 20,
+		}
+		switch listen = strings.TrimSpace(listen); {
+		case strings.HasPrefix(listen, "unix://"):
+			startUnixSocketServer(s, strings.TrimPrefix(listen, "unix://"))
+		case strings.HasPrefix(listen, "http://"):
+			startHTTPServer(s, strings.TrimPrefix(listen, "http://"))
+		case strings.HasPrefix(listen, "https://"):
+			if server.certificate == nil {
+				server.certificate = setupCertificate(cfg.Server.CertFile, cfg.Server.KeyFile)
+			}
+			s.TLSConfig = &tls.Config{
+				MinVersion:     tls.VersionTLS12,
+				GetCertificate: server.certificate.getCertificate,
+			}
+			startHTTPSServer(s, strings.TrimPrefix(listen, "https://"))
+		default:
+			logger.Fatal("not supported listen address: %s", listen)
+		}
+		server.servers = append(server.servers, s)
+	}
+	if timings != nil {
+		server.startProfile(cfg, timings)
+	}
+	if m != nil {
+		server.startMetrics(cfg, m)
 	}
 	return server
 }
 
+// Shutdown gracefully shuts down all listeners.
+func (s *Server) Shutdown(ctx context.Context) error {
+	var err error
+	for _, server := range s.servers {
+		if e := server.Shutdown(ctx); e != nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// Reload swap in websites config which are safe to change live,
+// and reload TLS certificate from its files.
+func (s *Server) Reload(cfgs []config.Config) {
+	if len(cfgs) != len(s.sites) {
+		logger.Error("amount of websites changed, restart to apply")
+	} else if err := checkReload(s.sites, cfgs); err != nil {
+		logger.Error("reload failed, keep the old config: %v", err)
+	} else {
+		for i, site := range s.sites {
+			site.reload(cfgs[i])
+		}
+	}
+
+	if s.certificate == nil {
+		return
+	}
+	if err := s.certificate.reload(); err != nil {
+		logger.Error("reload TLS certificate failed, keep the old one: %v", err)
+		return
+	}
+	logger.Info("TLS certificate reloaded")
+}
+
+func setupCertificate(certFile, keyFile string) *certificate {
+	if certFile == "" || keyFile == "" {
+		logger.Fatal("cert-file and key-file are required to listen on https://")
+	}
+	cert, err := loadCertificate(certFile, keyFile)
+	if err != nil {
+		logger.Fatal("load TLS certificate failed: %v", err)
+	}
+	return cert
+}
+
 func startUnixSocketServer(server *http.Server, socketFile string) {
 	os.Remove(socketFile)
 
@@ -60,43 +154,56 @@ func startUnixSocketServer(server *http.Server, socketFile string) {
 	}(socketFile)
 }
 
-func startHTTPServer(server *http.Server) {
+func startHTTPServer(server *http.Server, addr string) {
 	go func() {
-		logger.Info(`Listening on %q without TLS`, server.Addr)
-		if err := server.ListenAndServe(); err != http.ErrServerClosed {
+		listener, err := net.Listen("tcp", addr)
+		if err != nil {
+			logger.Fatal(`Server failed to start: %v`, err)
+		}
+		logger.Info(`Listening on %q without TLS`, addr)
+		if err := server.Serve(listener); err != http.ErrServerClosed {
 			logger.Fatal(`Server failed to start: %v`, err)
 		}
 	}()
 }
 
-func setupHandler(cfg config.Config) http.Handler {
-	router := mux.NewRouter()
-	router = router.MatcherFunc(func(r *http.Request, rm *mux.RouteMatch) bool {
-		origin := isso.FindOrigin(r)
-		for _, allowHost := range cfg.Host {
-			if origin == "" || origin == allowHost {
-				return true
-			}
+func startHTTPSServer(server *http.Server, addr string) {
+	go func() {
+		listener, err := net.Listen("tcp", addr)
+		if err != nil {
+			logger.Fatal(`Server failed to start: %v`, err)
 		}
-		return false
-	}).Subrouter()
-
-	storage, err := database.New(cfg.DBPath, 1*time.Second)
-	if err != nil {
-		logger.Fatal("init database failed %w", err)
-	}
-	registerRoute(router, isso.New(cfg, storage))
+		logger.Info(`Listening on %q with TLS`, addr)
+		// certificate comes from server.TLSConfig.
+		if err := server.ServeTLS(listener, "", ""); err != http.ErrServerClosed {
+			logger.Fatal(`Server failed to start: %v`, err)
+		}
+	}()
+}
 
-	c := cors.New(cors.Options{
-		AllowedOrigins:   cfg.Host,
-		AllowCredentials: true,
-		AllowedHeaders:   []string{"Origin", "Referer", "Content-Type"},
-		ExposedHeaders:   []string{"X-Set-Cookie", "Date"},
-		AllowedMethods:   []string{"HEAD", "GET", "POST", "PUT", "DELETE"},
-		Debug:            false,
-	})
+func setupHandler(sites []*site) http.Handler {
+	return setRequestID(sonyflakeRequestID())(dispatcher(sites))
+}
 
-	return setRequestID(sonyflakeRequestID())(c.Handler(router))
+// registerNotifiers return the smtp notifier if any, to reload its settings.
+func registerNotifiers(cfg config.Config, issoInstance *isso.ISSO, storage isso.Storage) *notify.SMTP {
+	var smtp *notify.SMTP
+	backends := cfg.Notify
+	if len(backends) == 0 {
+		backends = []string{"stdout"}
+	}
+	for _, backend := range backends {
+		switch strings.TrimSpace(backend) {
+		case "stdout":
+			issoInstance.RegisterNotifier(&notify.Logger{})
+		case "smtp":
+			smtp = notify.NewSMTP(cfg, storage, issoInstance)
+			issoInstance.RegisterNotifier(smtp)
+		default:
+			logger.Error("not supported notify backend: %s", backend)
+		}
+	}
+	return smtp
 }
 
 func setRequestID(nextRequestID func() string) func(http.Handler) http.Handler {
@@ -107,6 +214,7 @@ func setRequestID(nextRequestID func() string) func(http.Handler) http.Handler {
 				requestID = nextRequestID()
 			}
 			ctx := context.WithValue(r.Context(), isso.ISSOContextKey, requestID)
+			ctx = logger.NewContext(ctx, logger.FromContext(ctx).With("request_id", requestID))
 			w.Header().Set("X-Request-Id", requestID)
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
@@ -0,0 +1,244 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/cors"
+	"wrong.wang/x/go-isso/config"
+	"wrong.wang/x/go-isso/database"
+	"wrong.wang/x/go-isso/isso"
+	"wrong.wang/x/go-isso/logger"
+	"wrong.wang/x/go-isso/notify"
+	"wrong.wang/x/go-isso/response/json"
+)
+
+const descSiteNotFound = "no website matches the request"
+
+// site is a website served by go-isso, with its own database and ISSO instance.
+type site struct {
+	name    string
+	hosts   atomic.Value // []string
+	handler http.Handler
+
+	// config is only touched by reload.
+	config  config.Config
+	storage isso.Storage
+	isso    *isso.ISSO
+	smtp    *notify.SMTP
+}
+
+// newSite build a site, requests are timed when timings is not nil,
+// and measured when m is not nil.
+func newSite(cfg config.Config, timings *routeTimings, m *serverMetrics) *site {
+	s := &site{name: cfg.Name, config: cfg}
+	s.hosts.Store(cfg.Host)
+
+	db, err := database.New(cfg.DBPath, 1*time.Second)
+	if err != nil {
+		logger.Fatal("init database failed %v", err)
+	}
+	s.storage = db
+	if m != nil {
+		s.storage = meteredStorage{storage: db, metrics: m, site: cfg.Name}
+	}
+	s.isso = isso.New(cfg, s.storage)
+	s.smtp = registerNotifiers(cfg, s.isso, s.storage)
+	if m != nil {
+		s.isso.RegisterNotifier(m.observer(cfg.Name))
+	}
+
+	router := mux.NewRouter()
+	router = router.MatcherFunc(func(r *http.Request, rm *mux.RouteMatch) bool {
+		origin := isso.FindOrigin(r)
+		hosts := s.hosts.Load().([]string)
+		return len(hosts) > 0 && (origin == "" || s.allowOrigin(origin) || sameHost(origin, r))
+	}).Subrouter()
+	registerRoute(router, s.isso)
+	router.Use(s.ownPagesOnly)
+	if timings != nil {
+		router.Use(timings.middleware(cfg.Name))
+	}
+	if m != nil {
+		router.Use(m.middleware(cfg.Name))
+	}
+
+	c := cors.New(cors.Options{
+		AllowOriginFunc:  s.allowOrigin,
+		AllowCredentials: true,
+		AllowedHeaders:   []string{"Origin", "Referer", "Content-Type"},
+		ExposedHeaders:   []string{"X-Set-Cookie", "Date"},
+		AllowedMethods:   []string{"HEAD", "GET", "POST", "PUT", "DELETE"},
+		Debug:            false,
+	})
+	s.handler = c.Handler(router)
+	return s
+}
+
+// ownPageRoutes are requested by go-isso's own pages, whose Origin is go-isso itself.
+var ownPageRoutes = map[string]bool{
+	"admin":         true,
+	"admin_bulk":    true,
+	"login":         true,
+	"moderate_get":  true,
+	"moderate_post": true,
+}
+
+func sameHost(origin string, r *http.Request) bool {
+	u, err := url.Parse(origin)
+	return err == nil && u.Host == r.Host
+}
+
+// ownPagesOnly reject requests from go-isso's own origin to routes
+// other than ownPageRoutes.
+func (s *site) ownPagesOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := isso.FindOrigin(r)
+		if origin != "" && !s.allowOrigin(origin) {
+			if current := mux.CurrentRoute(r); current == nil || !ownPageRoutes[current.GetName()] {
+				http.NotFound(w, r)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// allowOrigin report whether origin is one of hosts of the site.
+func (s *site) allowOrigin(origin string) bool {
+	for _, host := range s.hosts.Load().([]string) {
+		if origin == host {
+			return true
+		}
+	}
+	return false
+}
+
+// reload swap in settings of cfg which are safe to change live,
+// others are logged as they need a restart.
+func (s *site) reload(cfg config.Config) {
+	cfg, changed := config.Reload(s.config, cfg)
+	if len(changed) > 0 {
+		logger.Info("website %q: restart to apply changed %s", s.name, strings.Join(changed, ", "))
+	}
+	if err := s.isso.Reload(cfg); err != nil {
+		logger.Error("website %q: reload failed, keep the old config: %v", s.name, err)
+		return
+	}
+	s.hosts.Store(cfg.Host)
+	if s.smtp != nil {
+		s.smtp.Reload(cfg)
+	}
+	s.config = cfg
+	logger.Info("website %q: config reloaded", s.name)
+}
+
+// checkSites make sure multiple websites can be told apart.
+func checkSites(cfgs []config.Config) error {
+	if len(cfgs) == 0 {
+		return errors.New("no website configured")
+	}
+	if len(cfgs) == 1 {
+		return nil
+	}
+	reserved := routeRoots()
+	names := map[string]bool{}
+	dbpaths := map[string]bool{}
+	hosts := map[string]string{}
+	for _, cfg := range cfgs {
+		if cfg.Name == "" || strings.ContainsAny(cfg.Name, "/?#") {
+			return fmt.Errorf("website name %q is invalid, name is required to dispatch multiple websites", cfg.Name)
+		}
+		if reserved[cfg.Name] {
+			return fmt.Errorf("website name %q is reserved, it is the root of a route", cfg.Name)
+		}
+		if names[cfg.Name] {
+			return fmt.Errorf("website name %q is used more than once", cfg.Name)
+		}
+		names[cfg.Name] = true
+		if dbpaths[cfg.DBPath] {
+			return fmt.Errorf("dbpath %q is shared by more than one website", cfg.DBPath)
+		}
+		dbpaths[cfg.DBPath] = true
+		// Origin is dispatched to the first website having it.
+		for _, host := range cfg.Host {
+			if name, ok := hosts[host]; ok && name != cfg.Name {
+				return fmt.Errorf("host %q is used by website %q and %q", host, name, cfg.Name)
+			}
+			hosts[host] = cfg.Name
+		}
+	}
+	return nil
+}
+
+// checkReload check configs of sites as they would be after reload.
+func checkReload(sites []*site, cfgs []config.Config) error {
+	next := make([]config.Config, len(cfgs))
+	for i, s := range sites {
+		next[i], _ = config.Reload(s.config, cfgs[i])
+	}
+	return checkSites(next)
+}
+
+// routeRoots return the first path segment of every route. Website names must
+// not be one of them, the prefix is matched before Origin and would hijack the route.
+func routeRoots() map[string]bool {
+	router := mux.NewRouter()
+	registerRoute(router, nil)
+	roots := map[string]bool{}
+	router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		if template, err := route.GetPathTemplate(); err == nil {
+			roots[strings.SplitN(strings.TrimPrefix(template, "/"), "/", 2)[0]] = true
+		}
+		return nil
+	})
+	return roots
+}
+
+// dispatcher route requests to one of sites, by the `/{name}` path prefix first,
+// then by Origin. A single site gets all requests without prefix.
+func dispatcher(sites []*site) http.Handler {
+	if len(sites) == 1 {
+		return sites[0].handler
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/"), "/", 2)[0]
+		for _, s := range sites {
+			if s.name == name {
+				s.serve(w, r, strings.TrimPrefix(r.URL.Path, "/"+name))
+				return
+			}
+		}
+		origin := isso.FindOrigin(r)
+		if origin != "" {
+			for _, s := range sites {
+				if s.allowOrigin(origin) {
+					s.serve(w, r, r.URL.Path)
+					return
+				}
+			}
+		}
+		json.NotFound(isso.RequestIDFromContext(r.Context()), w, nil, descSiteNotFound)
+	})
+}
+
+// serve pass request to the site with path stripped of site prefix.
+// Links generated by the site always carry the prefix, as requests without
+// Origin, e.g. moderation links in emails, can only be dispatched by it.
+func (s *site) serve(w http.ResponseWriter, r *http.Request, path string) {
+	if path == "" {
+		path = "/"
+	}
+	r = r.WithContext(isso.WithSitePrefix(r.Context(), "/"+s.name))
+	u := *r.URL
+	u.Path = path
+	u.RawPath = ""
+	r.URL = &u
+	s.handler.ServeHTTP(w, r)
+}
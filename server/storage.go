@@ -0,0 +1,147 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"wrong.wang/x/go-isso/isso"
+)
+
+// meteredStorage time every call of Storage and count errors.
+type meteredStorage struct {
+	storage isso.Storage
+	metrics *serverMetrics
+	site    string
+}
+
+func (s meteredStorage) observe(method string, start time.Time, err error) {
+	s.metrics.observeStorage(s.site, method, start, err)
+}
+
+func (s meteredStorage) GetThreadByURI(ctx context.Context, uri string) (t isso.Thread, err error) {
+	defer func(start time.Time) { s.observe("GetThreadByURI", start, err) }(time.Now())
+	return s.storage.GetThreadByURI(ctx, uri)
+}
+
+func (s meteredStorage) GetThreadByID(ctx context.Context, id int64) (t isso.Thread, err error) {
+	defer func(start time.Time) { s.observe("GetThreadByID", start, err) }(time.Now())
+	return s.storage.GetThreadByID(ctx, id)
+}
+
+func (s meteredStorage) NewThread(ctx context.Context, uri string, title string) (t isso.Thread, err error) {
+	defer func(start time.Time) { s.observe("NewThread", start, err) }(time.Now())
+	return s.storage.NewThread(ctx, uri, title)
+}
+
+func (s meteredStorage) FetchThreads(ctx context.Context) (ts []isso.Thread, err error) {
+	defer func(start time.Time) { s.observe("FetchThreads", start, err) }(time.Now())
+	return s.storage.FetchThreads(ctx)
+}
+
+func (s meteredStorage) RestoreThread(ctx context.Context, t isso.Thread) (err error) {
+	defer func(start time.Time) { s.observe("RestoreThread", start, err) }(time.Now())
+	return s.storage.RestoreThread(ctx, t)
+}
+
+func (s meteredStorage) IsApprovedAuthor(ctx context.Context, email string) bool {
+	defer func(start time.Time) { s.observe("IsApprovedAuthor", start, nil) }(time.Now())
+	return s.storage.IsApprovedAuthor(ctx, email)
+}
+
+func (s meteredStorage) NewComment(ctx context.Context, c isso.Comment, threadID int64, remoteAddr string) (nc isso.Comment, err error) {
+	defer func(start time.Time) { s.observe("NewComment", start, err) }(time.Now())
+	return s.storage.NewComment(ctx, c, threadID, remoteAddr)
+}
+
+func (s meteredStorage) GetComment(ctx context.Context, id int64) (c isso.Comment, err error) {
+	defer func(start time.Time) { s.observe("GetComment", start, err) }(time.Now())
+	return s.storage.GetComment(ctx, id)
+}
+
+func (s meteredStorage) ImportComment(ctx context.Context, c isso.Comment) (ic isso.Comment, err error) {
+	defer func(start time.Time) { s.observe("ImportComment", start, err) }(time.Now())
+	return s.storage.ImportComment(ctx, c)
+}
+
+func (s meteredStorage) RestoreComment(ctx context.Context, c isso.Comment) (err error) {
+	defer func(start time.Time) { s.observe("RestoreComment", start, err) }(time.Now())
+	return s.storage.RestoreComment(ctx, c)
+}
+
+func (s meteredStorage) CountReply(ctx context.Context, uri string, mode int, after float64) (counts map[int64]int64, err error) {
+	defer func(start time.Time) { s.observe("CountReply", start, err) }(time.Now())
+	return s.storage.CountReply(ctx, uri, mode, after)
+}
+
+func (s meteredStorage) FetchCommentsByURI(ctx context.Context, uri string, parent int64, mode int,
+	orderBy string, asc bool) (comments map[int64][]isso.Comment, err error) {
+	defer func(start time.Time) { s.observe("FetchCommentsByURI", start, err) }(time.Now())
+	return s.storage.FetchCommentsByURI(ctx, uri, parent, mode, orderBy, asc)
+}
+
+func (s meteredStorage) CountComment(ctx context.Context, uris []string) (counts map[string]int64, err error) {
+	defer func(start time.Time) { s.observe("CountComment", start, err) }(time.Now())
+	return s.storage.CountComment(ctx, uris)
+}
+
+func (s meteredStorage) FetchComments(ctx context.Context, mode int, orderBy string, asc bool,
+	limit int64, offset int64) (comments []isso.Comment, err error) {
+	defer func(start time.Time) { s.observe("FetchComments", start, err) }(time.Now())
+	return s.storage.FetchComments(ctx, mode, orderBy, asc, limit, offset)
+}
+
+func (s meteredStorage) CountCommentsByMode(ctx context.Context) (counts map[int]int64, err error) {
+	defer func(start time.Time) { s.observe("CountCommentsByMode", start, err) }(time.Now())
+	return s.storage.CountCommentsByMode(ctx)
+}
+
+func (s meteredStorage) ActivateComment(ctx context.Context, id int64) (err error) {
+	defer func(start time.Time) { s.observe("ActivateComment", start, err) }(time.Now())
+	return s.storage.ActivateComment(ctx, id)
+}
+
+func (s meteredStorage) EditComment(ctx context.Context, c isso.Comment) (ec isso.Comment, err error) {
+	defer func(start time.Time) { s.observe("EditComment", start, err) }(time.Now())
+	return s.storage.EditComment(ctx, c)
+}
+
+func (s meteredStorage) UnsubscribeComment(ctx context.Context, email string, id int64) (err error) {
+	defer func(start time.Time) { s.observe("UnsubscribeComment", start, err) }(time.Now())
+	return s.storage.UnsubscribeComment(ctx, email, id)
+}
+
+func (s meteredStorage) DeleteComment(ctx context.Context, cid int64) (c isso.Comment, err error) {
+	defer func(start time.Time) { s.observe("DeleteComment", start, err) }(time.Now())
+	return s.storage.DeleteComment(ctx, cid)
+}
+
+func (s meteredStorage) VoteComment(ctx context.Context, c isso.Comment, up bool) (err error) {
+	defer func(start time.Time) { s.observe("VoteComment", start, err) }(time.Now())
+	return s.storage.VoteComment(ctx, c, up)
+}
+
+func (s meteredStorage) GetPreference(ctx context.Context, key string) (value string, err error) {
+	defer func(start time.Time) { s.observe("GetPreference", start, err) }(time.Now())
+	return s.storage.GetPreference(ctx, key)
+}
+
+func (s meteredStorage) SetPreference(ctx context.Context, key string, value string) (err error) {
+	defer func(start time.Time) { s.observe("SetPreference", start, err) }(time.Now())
+	return s.storage.SetPreference(ctx, key, value)
+}
+
+func (s meteredStorage) UpdatePreference(ctx context.Context, key string, value string) (err error) {
+	defer func(start time.Time) { s.observe("UpdatePreference", start, err) }(time.Now())
+	return s.storage.UpdatePreference(ctx, key, value)
+}
+
+func (s meteredStorage) FetchPreferences(ctx context.Context) (preferences map[string]string, err error) {
+	defer func(start time.Time) { s.observe("FetchPreferences", start, err) }(time.Now())
+	return s.storage.FetchPreferences(ctx)
+}
+
+func (s meteredStorage) NewCommentGuard(ctx context.Context, c isso.Comment, uri string,
+	ratelimit int, directreply int, replytoself bool, maxage int) (bool, string, string) {
+	defer func(start time.Time) { s.observe("NewCommentGuard", start, nil) }(time.Now())
+	return s.storage.NewCommentGuard(ctx, c, uri, ratelimit, directreply, replytoself, maxage)
+}
@@ -0,0 +1,116 @@
+package server
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// selfSigned return a self-signed certificate for name and its key, both in PEM format.
+func selfSigned(t *testing.T, name string) (certPEM, keyPEM []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key failed: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("create certificate failed: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("marshal key failed: %v", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func writeFile(t *testing.T, path string, data []byte) {
+	t.Helper()
+	if err := ioutil.WriteFile(path, data, 0600); err != nil {
+		t.Fatalf("write %s failed: %v", path, err)
+	}
+}
+
+func servedName(t *testing.T, c *certificate) string {
+	t.Helper()
+	cert, err := c.getCertificate(nil)
+	if err != nil {
+		t.Fatalf("getCertificate() error = %v", err)
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatalf("parse served certificate failed: %v", err)
+	}
+	return leaf.Subject.CommonName
+}
+
+func Test_certificate_reload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-isso-tls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+
+	oldCert, oldKey := selfSigned(t, "old.example.com")
+	writeFile(t, certFile, oldCert)
+	writeFile(t, keyFile, oldKey)
+	c, err := loadCertificate(certFile, keyFile)
+	if err != nil {
+		t.Fatalf("loadCertificate() error = %v", err)
+	}
+	if name := servedName(t, c); name != "old.example.com" {
+		t.Fatalf("served %s, want old.example.com", name)
+	}
+
+	newCert, newKey := selfSigned(t, "new.example.com")
+	writeFile(t, certFile, newCert)
+	writeFile(t, keyFile, newKey)
+	if err := c.reload(); err != nil {
+		t.Fatalf("reload() error = %v", err)
+	}
+	if name := servedName(t, c); name != "new.example.com" {
+		t.Errorf("served %s after reload, want new.example.com", name)
+	}
+
+	t.Run("bad files keep the old certificate", func(t *testing.T) {
+		// the key does not match the certificate, e.g. only one file is replaced.
+		writeFile(t, certFile, oldCert)
+		if err := c.reload(); err == nil {
+			t.Errorf("reload() with mismatched key should fail")
+		}
+		writeFile(t, certFile, bytes.Repeat([]byte("x"), 10))
+		if err := c.reload(); err == nil {
+			t.Errorf("reload() with broken certificate should fail")
+		}
+		os.Remove(keyFile)
+		if err := c.reload(); err == nil {
+			t.Errorf("reload() without key file should fail")
+		}
+		if name := servedName(t, c); name != "new.example.com" {
+			t.Errorf("served %s after failed reloads, want new.example.com", name)
+		}
+	})
+
+	if _, err := loadCertificate(certFile, keyFile); err == nil {
+		t.Errorf("loadCertificate() with missing files should fail")
+	}
+}
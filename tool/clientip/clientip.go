@@ -0,0 +1,168 @@
+package clientip
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// Resolver find the client IP of requests, forwarded headers are honoured
+// only when they are added by trusted proxies.
+type Resolver struct {
+	trusted []*net.IPNet
+}
+
+// New return a Resolver trusting proxies, which are IP addresses or CIDR
+// ranges like `10.0.0.0/8`.
+func New(proxies []string) (*Resolver, error) {
+	r := &Resolver{}
+	for _, proxy := range proxies {
+		proxy = strings.TrimSpace(proxy)
+		if proxy == "" {
+			continue
+		}
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q", proxy)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			r.trusted = append(r.trusted, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, ipnet, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", proxy, err)
+		}
+		r.trusted = append(r.trusted, ipnet)
+	}
+	return r, nil
+}
+
+// ClientIP return the client IP of request. When the direct peer is a trusted
+// proxy, the chain in `Forwarded`, or `X-Forwarded-For`, is walked from the
+// right, and the first address not trusted is the client.
+func (r *Resolver) ClientIP(req *http.Request) string {
+	client := peerIP(req)
+	if !r.isTrusted(client) {
+		return client
+	}
+
+	chain := forwardedFor(req.Header.Values("Forwarded"))
+	if chain == nil {
+		chain = xForwardedFor(req.Header.Values("X-Forwarded-For"))
+	}
+	if chain == nil {
+		if ip := net.ParseIP(strings.TrimSpace(req.Header.Get("X-Real-Ip"))); ip != nil {
+			return ip.String()
+		}
+	}
+	for i := len(chain) - 1; i >= 0; i-- {
+		ip := net.ParseIP(chain[i])
+		if ip == nil {
+			// unknown or obfuscated hop, nothing left of it can be verified.
+			break
+		}
+		client = ip.String()
+		if !r.isTrusted(client) {
+			break
+		}
+	}
+	return client
+}
+
+func (r *Resolver) isTrusted(ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, ipnet := range r.trusted {
+		if ipnet.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// peerIP return IP of the TCP/IP peer.
+func peerIP(req *http.Request) string {
+	var remoteIP string
+	if strings.ContainsRune(req.RemoteAddr, ':') {
+		remoteIP, _, _ = net.SplitHostPort(req.RemoteAddr)
+	} else {
+		remoteIP = req.RemoteAddr
+	}
+
+	// When listening on a Unix socket, RemoteAddr is empty or `@`.
+	if net.ParseIP(remoteIP) == nil {
+		remoteIP = "127.0.0.1"
+	}
+	return remoteIP
+}
+
+func xForwardedFor(values []string) []string {
+	var chain []string
+	for _, value := range values {
+		for _, address := range strings.Split(value, ",") {
+			chain = append(chain, strings.TrimSpace(address))
+		}
+	}
+	return chain
+}
+
+// forwardedFor return `for` parameters in RFC 7239 Forwarded headers,
+// e.g. `for=192.0.2.60;proto=http, for="[2001:db8:cafe::17]:4711"`.
+// An element without `for` is kept as an unknown hop.
+func forwardedFor(values []string) []string {
+	var chain []string
+	for _, value := range values {
+		for _, element := range splitQuoted(value, ',') {
+			node := ""
+			for _, pair := range splitQuoted(element, ';') {
+				kv := strings.SplitN(pair, "=", 2)
+				if len(kv) == 2 && strings.EqualFold(strings.TrimSpace(kv[0]), "for") {
+					node = forwardedNode(strings.TrimSpace(kv[1]))
+				}
+			}
+			chain = append(chain, node)
+		}
+	}
+	return chain
+}
+
+// forwardedNode strip quotes and port of a node, obfuscated identifiers and
+// `unknown` are returned as they are and never parsed as IP.
+func forwardedNode(node string) string {
+	node = strings.Trim(node, `"`)
+	if strings.HasPrefix(node, "[") {
+		if end := strings.Index(node, "]"); end > 0 {
+			return node[1:end]
+		}
+		return ""
+	}
+	if host, _, err := net.SplitHostPort(node); err == nil {
+		return host
+	}
+	return node
+}
+
+// splitQuoted split s by sep outside of quoted strings.
+func splitQuoted(s string, sep rune) []string {
+	var parts []string
+	quoted := false
+	start := 0
+	for i, c := range s {
+		switch {
+		case c == '"':
+			quoted = !quoted
+		case c == sep && !quoted:
+			parts = append(parts, strings.TrimSpace(s[start:i]))
+			start = i + 1
+		}
+	}
+	return append(parts, strings.TrimSpace(s[start:]))
+}
@@ -0,0 +1,71 @@
+package clientip
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		proxies []string
+		wantErr bool
+	}{
+		{"empty", nil, false},
+		{"ip and cidr", []string{"127.0.0.1", " 10.0.0.0/8", "::1", "fd00::/8", ""}, false},
+		{"invalid ip", []string{"localhost"}, true},
+		{"invalid cidr", []string{"10.0.0.0/33"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(tt.proxies); (err != nil) != tt.wantErr {
+				t.Errorf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestResolver_ClientIP(t *testing.T) {
+	r, err := New([]string{"127.0.0.1", "10.0.0.0/8", "::1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name       string
+		remoteAddr string
+		headers    map[string]string
+		want       string
+	}{
+		{"no header", "192.0.2.1:1234", nil, "192.0.2.1"},
+		{"untrusted peer", "192.0.2.1:1234", map[string]string{"X-Forwarded-For": "198.51.100.1"}, "192.0.2.1"},
+		{"untrusted peer real ip", "192.0.2.1:1234", map[string]string{"X-Real-Ip": "198.51.100.1"}, "192.0.2.1"},
+		{"trusted peer", "127.0.0.1:1234", map[string]string{"X-Forwarded-For": "198.51.100.1"}, "198.51.100.1"},
+		{"trusted peer real ip", "127.0.0.1:1234", map[string]string{"X-Real-Ip": "198.51.100.1"}, "198.51.100.1"},
+		{"trusted peer no header", "127.0.0.1:1234", nil, "127.0.0.1"},
+		{"spoofed left", "127.0.0.1:1234", map[string]string{"X-Forwarded-For": "1.1.1.1, 198.51.100.1, 10.1.2.3"}, "198.51.100.1"},
+		{"all trusted", "127.0.0.1:1234", map[string]string{"X-Forwarded-For": "10.0.0.2, 10.0.0.1"}, "10.0.0.2"},
+		{"garbage hop", "127.0.0.1:1234", map[string]string{"X-Forwarded-For": "198.51.100.1, nonsense"}, "127.0.0.1"},
+		{"ipv6 peer", "[::1]:1234", map[string]string{"X-Forwarded-For": "2001:db8::1"}, "2001:db8::1"},
+		{"unix socket", "@", map[string]string{"X-Forwarded-For": "198.51.100.1"}, "198.51.100.1"},
+		{"forwarded", "127.0.0.1:1234", map[string]string{"Forwarded": `for=192.0.2.60;proto=http;by=203.0.113.43`}, "192.0.2.60"},
+		{"forwarded chain", "127.0.0.1:1234", map[string]string{"Forwarded": `for=1.1.1.1, for="[2001:db8:cafe::17]:4711", for=10.0.0.1:80`}, "2001:db8:cafe::17"},
+		{"forwarded over x-forwarded-for", "127.0.0.1:1234", map[string]string{
+			"Forwarded": "for=192.0.2.60", "X-Forwarded-For": "198.51.100.1"}, "192.0.2.60"},
+		{"forwarded unknown", "127.0.0.1:1234", map[string]string{"Forwarded": "for=192.0.2.60, for=unknown"}, "127.0.0.1"},
+		{"forwarded obfuscated", "127.0.0.1:1234", map[string]string{"Forwarded": `for=192.0.2.60, for="_hidden"`}, "127.0.0.1"},
+		{"forwarded without for", "127.0.0.1:1234", map[string]string{"Forwarded": "for=192.0.2.60, proto=https"}, "127.0.0.1"},
+		{"forwarded quoted comma", "127.0.0.1:1234", map[string]string{"Forwarded": `for=192.0.2.60;ext="a,b"`}, "192.0.2.60"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			if got := r.ClientIP(req); got != tt.want {
+				t.Errorf("Resolver.ClientIP() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,19 +2,27 @@ package hash
 
 import (
 	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
 	"encoding/hex"
+	"fmt"
+	"hash"
 	"strconv"
 	"strings"
 
 	"golang.org/x/crypto/pbkdf2"
-	"wrong.wang/x/go-isso/logger"
 )
 
-// Worker can hash password with pbkdf2
+var digests = map[string]func() hash.Hash{
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+// Worker can hash password with pbkdf2 or a salted digest
 type Worker struct {
-	salt   []byte
-	iter   int
-	keyLen int
+	salt []byte
+	sum  func(p []byte) []byte
 }
 
 // New return a new HashWorker with conf like this : `pbkdf2:arg1:arg2:arg3`.
@@ -22,37 +30,58 @@ type Worker struct {
 // iterations, 6 bytes to generate and SHA1 as pseudo-random family used for key
 // strengthening. Arguments have to be in that order, but can be reduced to
 // pbkdf2:4096 for example to override the iterations only.
-// TODO: support all possibility for isso config
-func New(conf, salt string) *Worker {
+// sha1, sha256 and sha512 as conf hash salt followed by the string with that digest.
+// Empty conf means pbkdf2.
+func New(conf, salt string) (*Worker, error) {
+	if conf == "" {
+		conf = "pbkdf2"
+	}
+	w := &Worker{salt: []byte(salt)}
+	if digest, ok := digests[conf]; ok {
+		w.sum = func(p []byte) []byte {
+			h := digest()
+			h.Write(w.salt)
+			h.Write(p)
+			return h.Sum(nil)
+		}
+		return w, nil
+	}
+
 	r := strings.Split(conf, ":")
 	if r[0] != "pbkdf2" {
-		logger.Fatal("go-isso just support pbkdf2 for now")
+		return nil, fmt.Errorf("hash conf error - not supported algorithm %s", r[0])
 	}
 	iter := 1000
 	keyLen := 6
+	digest := sha1.New
 	var err error
 
 	if len(r) >= 2 {
 		iter, err = strconv.Atoi(r[1])
-		if err != nil {
-			logger.Fatal("hash conf error - convert arg1(%s) failed: %w", r[1], err)
+		if err != nil || iter <= 0 {
+			return nil, fmt.Errorf("hash conf error - convert arg1(%s) failed: %v", r[1], err)
 		}
 	}
 	if len(r) >= 3 {
 		keyLen, err = strconv.Atoi(r[2])
-		if err != nil {
-			logger.Fatal("hash conf error - convert arg2(%s) failed: %w", r[2], err)
+		if err != nil || keyLen <= 0 {
+			return nil, fmt.Errorf("hash conf error - convert arg2(%s) failed: %v", r[2], err)
+		}
+	}
+	if len(r) >= 4 {
+		var ok bool
+		if digest, ok = digests[r[3]]; !ok {
+			return nil, fmt.Errorf("hash conf error - not supported digest arg3(%s)", r[3])
 		}
 	}
 
-	return &Worker{
-		iter:   iter,
-		keyLen: keyLen,
-		salt:   []byte(salt),
+	w.sum = func(p []byte) []byte {
+		return pbkdf2.Key(p, w.salt, iter, keyLen, digest)
 	}
+	return w, nil
 }
 
 // Hash hash a string to a hex string.
 func (h *Worker) Hash(p string) string {
-	return hex.EncodeToString(pbkdf2.Key([]byte(p), h.salt, h.iter, h.keyLen, sha1.New))
+	return hex.EncodeToString(h.sum([]byte(p)))
 }
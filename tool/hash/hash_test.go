@@ -0,0 +1,39 @@
+package hash
+
+import "testing"
+
+func TestWorker_Hash(t *testing.T) {
+	tests := []struct {
+		conf string
+		salt string
+		p    string
+		want string
+	}{
+		{"", "Eech7co8Ohloopo9Ol6baimi", "127.0.0.1", "c1d80bb4112d"},
+		{"pbkdf2", "Eech7co8Ohloopo9Ol6baimi", "127.0.0.1", "c1d80bb4112d"},
+		{"pbkdf2:1000:6:sha1", "Eech7co8Ohloopo9Ol6baimi", "127.0.0.1", "c1d80bb4112d"},
+		{"pbkdf2:10:8:sha256", "salt", "127.0.0.1", "55b96f848b261a7b"},
+		{"pbkdf2:2000:6:sha512", "salt", "a@b.c", "7f37b148331e"},
+		{"sha1", "salt", "127.0.0.1", "6a0149e8527fbd0cf24cd04e900f1381e87882b2"},
+		{"sha256", "salt", "127.0.0.1", "31fd09bbc26a705a9c4006b2eb7b3d8a60d47168b7faa3eb5e4e2f176f4c0b85"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.conf, func(t *testing.T) {
+			w, err := New(tt.conf, tt.salt)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			if got := w.Hash(tt.p); got != tt.want {
+				t.Errorf("Worker.Hash() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	for _, conf := range []string{"md4", "pbkdf2:many", "pbkdf2:1000:0", "pbkdf2:1000:6:md5"} {
+		if _, err := New(conf, "salt"); err == nil {
+			t.Errorf("New(%q) should fail", conf)
+		}
+	}
+}
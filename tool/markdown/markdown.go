@@ -5,24 +5,29 @@ import (
 	"fmt"
 
 	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/renderer/html"
 )
 
 // Worker do markdown convert
 type Worker struct {
 	m goldmark.Markdown
+	s *sanitizer
 }
 
-// Convert markdown to html
+// Convert markdown to sanitized html
 func (w *Worker) Convert(source string) (string, error) {
 	var buf bytes.Buffer
 	err := w.m.Convert([]byte(source), &buf)
 	if err != nil {
 		return "", fmt.Errorf("markdown convert failed %w", err)
 	}
-	return buf.String(), nil
+	return w.s.sanitize(buf.String()), nil
 }
 
-// New return a converter worker
-func New() *Worker {
-	return &Worker{goldmark.New()}
+// New return a converter worker, allowedElements and allowedAttributes
+// extend the default allowed HTML in output.
+// Raw HTML in comments is rendered like isso does, every output is sanitized.
+func New(allowedElements, allowedAttributes []string) *Worker {
+	m := goldmark.New(goldmark.WithRendererOptions(html.WithUnsafe()))
+	return &Worker{m, newSanitizer(allowedElements, allowedAttributes)}
 }
@@ -0,0 +1,62 @@
+package markdown
+
+import "testing"
+
+func TestWorker_Convert(t *testing.T) {
+	tests := []struct {
+		name       string
+		elements   []string
+		attributes []string
+		source     string
+		want       string
+	}{
+		{"emphasis", nil, nil, "*hi* **there**", "<p><em>hi</em> <strong>there</strong></p>\n"},
+		{"link", nil, nil, "[go](https://golang.org)", `<p><a href="https://golang.org" rel="nofollow noopener">go</a></p>` + "\n"},
+		{"javascript link", nil, nil, "[x](javascript:alert(1))", `<p><a rel="nofollow noopener">x</a></p>` + "\n"},
+		{"raw html", nil, nil, "hi <b onclick=x()>there</b>", "<p>hi there</p>\n"},
+		{"raw sup not allowed", nil, nil, "x<sup>2</sup>", "<p>x2</p>\n"},
+		{"raw sup allowed", []string{"sup"}, nil, "x<sup>2</sup>", "<p>x<sup>2</sup></p>\n"},
+		{"raw image not allowed", nil, nil, `<img src="https://example.com/a.png" onerror="x()">`, ""},
+		{"raw image allowed", []string{"img"}, []string{"src"}, `<img src="https://example.com/a.png" onerror="x()">`,
+			`<img src="https://example.com/a.png">`},
+		{"raw script", nil, nil, "<script>alert(1)</script>\n\nhi", "\n<p>hi</p>\n"},
+		{"image not allowed", nil, nil, "![alt](https://example.com/a.png)", "<p></p>\n"},
+		{"image allowed", []string{"img"}, []string{"src", "alt"}, "![alt](https://example.com/a.png)",
+			`<p><img src="https://example.com/a.png" alt="alt"></p>` + "\n"},
+		{"fenced code", nil, nil, "```go\na < b\n```", "<pre><code>a &lt; b\n</code></pre>\n"},
+		{"event handler", []string{"img"}, []string{"src", "onerror"}, "![x](https://example.com/a.png)",
+			`<p><img src="https://example.com/a.png"></p>` + "\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := New(tt.elements, tt.attributes).Convert(tt.source)
+			if err != nil {
+				t.Fatalf("Worker.Convert() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Worker.Convert() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_sanitizer_sanitize(t *testing.T) {
+	s := newSanitizer(nil, nil)
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{`<p onclick="x()">a</p>`, `<p>a</p>`},
+		{`<a href=" JaVaScRiPt:alert(1)">a</a>`, `<a rel="nofollow noopener">a</a>`},
+		{`<a href="/relative" rel="me">a</a>`, `<a href="/relative" rel="nofollow noopener">a</a>`},
+		{`<div><style>p{}</style>b</div>`, `b`},
+		{`<p title="&quot;><script>">a</p>`, `<p>a</p>`},
+		{`<p align="&quot;>x">a &amp; b</p>`, `<p align="&#34;&gt;x">a &amp; b</p>`},
+		{`<!-- comment -->c`, `c`},
+	}
+	for _, tt := range tests {
+		if got := s.sanitize(tt.source); got != tt.want {
+			t.Errorf("sanitize(%q) = %q, want %q", tt.source, got, tt.want)
+		}
+	}
+}
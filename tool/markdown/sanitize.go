@@ -0,0 +1,121 @@
+package markdown
+
+import (
+	"html"
+	"io"
+	"net/url"
+	"strings"
+
+	xhtml "golang.org/x/net/html"
+)
+
+// same defaults as isso.
+var (
+	defaultElements = []string{
+		"a", "blockquote", "br", "code", "del", "em", "h1", "h2", "h3", "h4", "h5", "h6",
+		"hr", "ins", "li", "ol", "p", "pre", "strong", "table", "tbody", "td", "th", "thead", "ul",
+	}
+	defaultAttributes = []string{"align", "href"}
+)
+
+// attributes hold URL, only safe schemes are kept.
+var urlAttributes = map[string]bool{
+	"href": true, "src": true, "cite": true, "action": true, "formaction": true, "poster": true, "background": true,
+}
+
+// elements whose content is dropped with them.
+var rawTextElements = map[string]bool{
+	"script": true, "style": true, "textarea": true, "title": true, "noscript": true, "xmp": true, "iframe": true,
+}
+
+// sanitizer keep allowed elements and attributes, and drop everything else.
+type sanitizer struct {
+	elements   map[string]bool
+	attributes map[string]bool
+}
+
+func newSanitizer(elements, attributes []string) *sanitizer {
+	s := &sanitizer{elements: map[string]bool{}, attributes: map[string]bool{}}
+	for _, e := range append(defaultElements, elements...) {
+		if e = strings.ToLower(strings.TrimSpace(e)); e != "" {
+			s.elements[e] = true
+		}
+	}
+	for _, a := range append(defaultAttributes, attributes...) {
+		a = strings.ToLower(strings.TrimSpace(a))
+		// event handlers and styles are never allowed.
+		if a == "" || strings.HasPrefix(a, "on") || a == "style" {
+			continue
+		}
+		s.attributes[a] = true
+	}
+	return s
+}
+
+func (s *sanitizer) sanitize(source string) string {
+	var b strings.Builder
+	z := xhtml.NewTokenizer(strings.NewReader(source))
+	var skip string
+	for {
+		tt := z.Next()
+		if tt == xhtml.ErrorToken {
+			if z.Err() != io.EOF {
+				// tokenizer never fails on reader of string, keep nothing just in case.
+				return ""
+			}
+			return b.String()
+		}
+		token := z.Token()
+		if skip != "" {
+			if tt == xhtml.EndTagToken && token.Data == skip {
+				skip = ""
+			}
+			continue
+		}
+
+		switch tt {
+		case xhtml.TextToken:
+			b.WriteString(html.EscapeString(token.Data))
+		case xhtml.StartTagToken, xhtml.SelfClosingTagToken:
+			if !s.elements[token.Data] {
+				if tt == xhtml.StartTagToken && rawTextElements[token.Data] {
+					skip = token.Data
+				}
+				continue
+			}
+			b.WriteString("<" + token.Data)
+			for _, attr := range token.Attr {
+				if attr.Namespace != "" || !s.attributes[attr.Key] || (attr.Key == "rel" && token.Data == "a") {
+					continue
+				}
+				if urlAttributes[attr.Key] && !safeURL(attr.Val) {
+					continue
+				}
+				b.WriteString(" " + attr.Key + `="` + html.EscapeString(attr.Val) + `"`)
+			}
+			if token.Data == "a" {
+				b.WriteString(` rel="nofollow noopener"`)
+			}
+			if tt == xhtml.SelfClosingTagToken {
+				b.WriteString(" /")
+			}
+			b.WriteString(">")
+		case xhtml.EndTagToken:
+			if s.elements[token.Data] {
+				b.WriteString("</" + token.Data + ">")
+			}
+		}
+	}
+}
+
+func safeURL(s string) bool {
+	u, err := url.Parse(strings.TrimSpace(s))
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "", "http", "https", "mailto":
+		return true
+	}
+	return false
+}